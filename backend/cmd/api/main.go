@@ -2,16 +2,22 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
+	_ "time/tzdata" // sendAt timezones without relying on the host's zoneinfo
 
 	"github.com/joho/godotenv"
 
 	"os"
 	httpapi "safe-notify/internal/http"
 	kafkaproducer "safe-notify/internal/queue"
+	"safe-notify/internal/ratelimit"
 	"safe-notify/internal/store"
+	"safe-notify/internal/tenant"
+	"safe-notify/internal/unsubscribe"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/cors"
@@ -29,12 +35,61 @@ func main() {
 	kafkaBrokers := os.Getenv("KAFKA_BROKERS")
 	kafkaTopic := os.Getenv("KAFKA_TOPIC_TASKS")
 	fmt.Println(kafkaTopic)
-	prod := kafkaproducer.NewProducer(kafkaBrokers, kafkaTopic)
+	prod := kafkaproducer.NewLaneProducer(kafkaBrokers, kafkaTopic)
 	defer prod.Close()
 
 	app := &httpapi.App{
 		Store:         st,
 		TasksProducer: prod,
+		SNSToken:      os.Getenv("SES_SNS_TOKEN"),
+		AdminAPIKey:   os.Getenv("ADMIN_API_KEY"),
+
+		DefaultCountryCode: os.Getenv("SMS_DEFAULT_COUNTRY_CODE"),
+	}
+	if raw := os.Getenv("FALLBACK_CHAINS"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &app.FallbackChains); err != nil {
+			log.Fatal("FALLBACK_CHAINS must be a JSON object of event_type -> steps:", err)
+		}
+	}
+	if raw := os.Getenv("DIGEST_WINDOWS"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &app.DigestWindows); err != nil {
+			log.Fatal("DIGEST_WINDOWS must be a JSON object of event_type -> seconds:", err)
+		}
+	}
+	if raw := os.Getenv("THROTTLE_RULES"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &app.ThrottleRules); err != nil {
+			log.Fatal("THROTTLE_RULES must be a JSON object of event_type -> rule:", err)
+		}
+	}
+	if app.Tenants, err = tenant.Load(); err != nil {
+		log.Fatal(err)
+	}
+	if raw := os.Getenv("API_RATE_LIMITS"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &app.RequestLimits); err != nil {
+			log.Fatal("API_RATE_LIMITS must be a JSON object of route -> limits:", err)
+		}
+		if err := app.RequestLimits.Validate(); err != nil {
+			log.Fatal("API_RATE_LIMITS: ", err)
+		}
+		switch backend := getenv("API_RATE_LIMIT_BACKEND", "memory"); backend {
+		case "memory":
+			app.RequestCounters = ratelimit.NewMemory()
+		case "dynamo":
+			app.RequestCounters = st // shared by every API replica
+		default:
+			log.Fatal("API_RATE_LIMIT_BACKEND must be memory or dynamo, got ", backend)
+		}
+	}
+	app.TrustForwardedFor = os.Getenv("TRUST_X_FORWARDED_FOR") == "true"
+	app.AllowPrivateWebhooks = os.Getenv("WEBHOOK_ALLOW_PRIVATE") == "true"
+	if app.SNSToken == "" {
+		log.Println("SES_SNS_TOKEN is not set; POST /ses/notifications is disabled and bounces are not suppressed")
+	}
+	if app.AdminAPIKey == "" {
+		log.Println("ADMIN_API_KEY is not set; only keys already in DYNAMO_API_KEYS_TABLE can call the API")
+	}
+	if secret := os.Getenv("UNSUBSCRIBE_SECRET"); secret != "" {
+		app.Unsubscribe = unsubscribe.NewSigner(secret, os.Getenv("PUBLIC_API_URL"))
 	}
 	// 2) create your app container (dependencies)
 	// app := &httpapi.App{
@@ -44,9 +99,10 @@ func main() {
 	// rand.Seed(time.Now().UnixNano())
 	// basic middleware
 	r.Use(cors.Handler(cors.Options{
-		AllowedOrigins: []string{"*"},
-		AllowedMethods: []string{"GET", "POST", "OPTIONS"},
-		AllowedHeaders: []string{"Accept", "Authorization", "Content-Type"},
+		AllowedOrigins: strings.Split(getenv("CORS_ALLOWED_ORIGINS", "http://localhost:5173"), ","),
+		AllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedHeaders: []string{"Accept", "Authorization", "Content-Type", "X-API-Key"},
+		ExposedHeaders: []string{"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy", "Retry-After", "X-Quota-Warning"},
 	}))
 
 	httpapi.RegisterRoutes(r, app)
@@ -55,3 +111,10 @@ func main() {
 	log.Fatal(http.ListenAndServe(":8080", r))
 
 }
+
+func getenv(k, def string) string {
+	if v := os.Getenv(k); v != "" {
+		return v
+	}
+	return def
+}
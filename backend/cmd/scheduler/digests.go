@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"safe-notify/internal/models"
+	kafkaproducer "safe-notify/internal/queue"
+	"safe-notify/internal/store"
+	"safe-notify/internal/tenant"
+)
+
+// dispatchDueDigests closes every digest window that has ended and queues one
+// digest task carrying its still-BATCHED tasks. The digest task ID is fixed
+// per window, so a scheduler that dies halfway re-queues the same task.
+func dispatchDueDigests(ctx context.Context, st *store.DynamoStore, lanes *kafkaproducer.LaneProducer, tenants tenant.Registry, nowMs int64) error {
+	due, err := st.FetchDueDigests(ctx, nowMs)
+	if err != nil {
+		return err
+	}
+
+	for _, d := range due {
+		closed, err := st.CloseDigest(ctx, d.DigestKey, nowMs)
+		if err != nil {
+			return err
+		}
+		if closed == nil {
+			continue
+		}
+
+		ids := make([]string, 0, len(closed.Entries))
+		for _, e := range closed.Entries {
+			ids = append(ids, e.TaskID)
+		}
+		members, err := st.GetTasksByIDs(ctx, ids)
+		if err != nil {
+			return err
+		}
+		batched := map[string]bool{}
+		for _, m := range members {
+			if m.Status == "BATCHED" && m.DigestTaskID == closed.DigestTaskID {
+				batched[m.TaskID] = true
+			}
+		}
+		var entries []models.DigestEntry
+		for _, e := range closed.Entries {
+			if batched[e.TaskID] {
+				entries = append(entries, e) // cancelled members drop out
+			}
+		}
+
+		if len(entries) > 0 {
+			task := digestTask(*closed, entries, tenants.MaxAttempts(closed.TenantID), nowMs)
+			if _, err := st.CreateTask(ctx, task); err != nil {
+				return err
+			}
+			if err := lanes.PublishTask(ctx, task.TaskID, task.Priority); err != nil {
+				return err
+			}
+			log.Println("scheduler: queued digest", task.TaskID, "with", len(entries), "tasks")
+		}
+
+		if err := st.MarkDigestDispatched(ctx, d.DigestKey, nowMs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func digestTask(d models.Digest, entries []models.DigestEntry, maxAttempts int, nowMs int64) models.Task {
+	entities := make([]string, 0, len(entries))
+	priority := entries[0].Priority
+	for _, e := range entries {
+		entities = append(entities, e.EntityID)
+		if e.Priority == "HIGH" {
+			priority = "HIGH"
+		}
+	}
+	summary := strings.Join(entities, ", ")
+	if len(entities) > 5 {
+		summary = strings.Join(entities[:5], ", ") + fmt.Sprintf(" and %d more", len(entities)-5)
+	}
+
+	task := models.Task{
+		TaskID:         d.DigestTaskID,
+		IdempotencyKey: "digest:" + d.DigestKey,
+		TenantID:       d.TenantID,
+		EventType:      d.EventType,
+		EntityID:       fmt.Sprintf("%d updates: %s", len(entries), summary), // what non-email channels show
+		Channel:        d.Channel,
+		Recipient:      d.Recipient,
+		Priority:       priority,
+		Digest:         entries,
+		Status:         "PENDING",
+		MaxAttempts:    maxAttempts,
+		CreatedAt:      nowMs,
+		UpdatedAt:      nowMs,
+	}
+	if task.Channel == "EMAIL" {
+		task.RecipientEmail = task.Recipient
+	}
+	return task
+}
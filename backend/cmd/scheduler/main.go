@@ -4,12 +4,16 @@ import (
 	"context"
 	"log"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
 
+	"safe-notify/internal/models"
 	kafkaproducer "safe-notify/internal/queue"
+	"safe-notify/internal/store"
+	"safe-notify/internal/tenant"
 )
 
 func main() {
@@ -25,8 +29,21 @@ func main() {
 	retryConsumer := kafkaproducer.NewConsumer(splitCSV(brokersCSV), retryTopic, groupID)
 	defer retryConsumer.Close()
 
-	mainProducer := kafkaproducer.NewProducer(brokersCSV, mainTopic)
-	defer mainProducer.Close()
+	// One producer per priority lane; the HIGH lane is the main topic
+	lanes := kafkaproducer.NewLaneProducer(brokersCSV, mainTopic)
+	defer lanes.Close()
+
+	// Dynamo store (scheduled sends, cancellation checks)
+	st, err := store.NewDynamoStore(ctx)
+	if err != nil {
+		log.Fatal("scheduler: init dynamo:", err)
+	}
+	tenants, err := tenant.Load()
+	if err != nil {
+		log.Fatal("scheduler: ", err)
+	}
+	pollEvery := time.Duration(getenvInt("SCHEDULER_POLL_MS", 5000)) * time.Millisecond
+	go releaseScheduled(ctx, st, lanes, tenants, pollEvery)
 
 	log.Println("scheduler: started retryTopic=", retryTopic, "mainTopic=", mainTopic)
 
@@ -43,8 +60,24 @@ func main() {
 			time.Sleep(time.Duration(rm.NextRetryAt-now) * time.Millisecond)
 		}
 
-		// publish back to main topic
-		if err := mainProducer.PublishTask(ctx, rm.TaskID); err != nil {
+		// publish back to the task's lane (webhooks: main topic), keeping the message kind
+		publish := func() error { return lanes.Main().PublishWebhook(ctx, rm.TaskID) }
+		if rm.Kind == kafkaproducer.KindTask {
+			task, err := st.GetTaskByID(ctx, rm.TaskID)
+			if err != nil {
+				log.Println("scheduler: load task failed:", err)
+				continue
+			}
+			// Drop retries for tasks cancelled while they waited
+			if task == nil || models.IsTerminal(task.Status) {
+				if err := commit(ctx); err != nil {
+					log.Println("scheduler: commit error:", err)
+				}
+				continue
+			}
+			publish = func() error { return lanes.PublishTask(ctx, task.TaskID, task.Priority) }
+		}
+		if err := publish(); err != nil {
 			log.Println("scheduler: publish main failed:", err)
 			// do not commit; will retry
 			continue
@@ -68,6 +101,14 @@ func splitCSV(s string) []string {
 	return out
 }
 
+func getenvInt(k string, def int) int {
+	v, err := strconv.Atoi(os.Getenv(k))
+	if err != nil {
+		return def
+	}
+	return v
+}
+
 func getenv(k, def string) string {
 	v := os.Getenv(k)
 	if v == "" {
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"safe-notify/internal/cron"
+	"safe-notify/internal/models"
+	kafkaproducer "safe-notify/internal/queue"
+	"safe-notify/internal/store"
+	"safe-notify/internal/tenant"
+)
+
+// fireDueSchedules materialises the due occurrence of every recurring
+// schedule. Event and task IDs derive from (schedule, occurrence), so if the
+// scheduler dies between writing tasks and advancing next_run_at, the next
+// poll finds the same IDs and only re-publishes; the worker's claim makes
+// that harmless. A schedule that fails is logged and retried on the next
+// poll without holding up the others.
+//
+// Each occurrence counts against the tenant's quotas like a POST /events
+// with the same targets. One a hard quota refuses is skipped, not retried.
+func fireDueSchedules(ctx context.Context, st *store.DynamoStore, lanes *kafkaproducer.LaneProducer, tenants tenant.Registry, nowMs int64) error {
+	due, err := st.FetchDueSchedules(ctx, nowMs)
+	if err != nil {
+		return err
+	}
+
+	for _, sch := range due {
+		occ := sch.NextRunAt
+		now := time.UnixMilli(nowMs)
+		quota, err := tenants.Get(sch.TenantID).ReserveQuota(ctx, st, sch.TenantID, len(sch.Targets), now)
+		if err != nil {
+			log.Println("scheduler: schedule", sch.ScheduleID, "quota check failed:", err)
+			continue
+		}
+		if quota.Exceeded {
+			log.Println("scheduler: schedule", sch.ScheduleID, "skipped occurrence", occ, "-", quota.Reason)
+		} else {
+			created, err := materialize(ctx, st, lanes, sch, occ, tenants.MaxAttempts(sch.TenantID), nowMs)
+			if !created {
+				// Failed, or counted when an earlier poll created it
+				if rerr := tenant.ReleaseQuota(ctx, st, sch.TenantID, len(sch.Targets), now); rerr != nil {
+					log.Println("scheduler: release quota failed:", sch.ScheduleID, rerr)
+				}
+			}
+			if err != nil {
+				log.Println("scheduler: schedule", sch.ScheduleID, "occurrence", occ, "failed:", err)
+				continue
+			}
+		}
+
+		// Occurrences missed while the scheduler was down collapse into this one
+		after := occ
+		if nowMs > after {
+			after = nowMs
+		}
+		next, err := cron.NextRun(sch.Cron, sch.Timezone, after, sch.StartAt, sch.EndAt)
+		if err != nil {
+			log.Println("scheduler: schedule", sch.ScheduleID, "has a bad cron, stopping it:", err)
+			next = 0
+		}
+		if _, err := st.AdvanceSchedule(ctx, sch.ScheduleID, occ, next, nowMs); err != nil {
+			log.Println("scheduler: advance schedule", sch.ScheduleID, "failed:", err)
+			continue
+		}
+		if !quota.Exceeded {
+			log.Println("scheduler: fired schedule", sch.ScheduleID, "occurrence", occ)
+		}
+	}
+	return nil
+}
+
+// materialize creates and publishes one occurrence of sch. created is false
+// if the occurrence's event already existed, or on an error before it was
+// stored.
+func materialize(ctx context.Context, st *store.DynamoStore, lanes *kafkaproducer.LaneProducer, sch models.Schedule, occ int64, maxAttempts int, nowMs int64) (created bool, err error) {
+	event := models.Event{
+		EventID:   fmt.Sprintf("evt_%s_%d", sch.ScheduleID, occ),
+		TenantID:  sch.TenantID,
+		EventType: sch.EventType,
+		EntityID:  sch.EntityID,
+		Priority:  sch.Priority,
+		CreatedAt: nowMs,
+	}
+
+	tasks := make([]models.Task, 0, len(sch.Targets))
+	for i, t := range sch.Targets {
+		task := models.Task{
+			TaskID:         fmt.Sprintf("task_%s_%d_%d", sch.ScheduleID, occ, i),
+			IdempotencyKey: fmt.Sprintf("%s:%d:%s:%s", sch.ScheduleID, occ, t.Channel, t.Recipient),
+			EventID:        event.EventID,
+			TenantID:       sch.TenantID,
+			EventType:      sch.EventType,
+			EntityID:       sch.EntityID,
+			Channel:        t.Channel,
+			Recipient:      t.Recipient,
+			Priority:       sch.Priority,
+			CallbackURL:    sch.CallbackURL,
+			Fallback:       t.Fallback,
+			Timezone:       sch.Timezone,
+			Status:         "PENDING",
+			MaxAttempts:    maxAttempts,
+			CreatedAt:      nowMs,
+			UpdatedAt:      nowMs,
+		}
+		if t.Channel == "EMAIL" {
+			task.RecipientEmail = t.Recipient
+		}
+		tasks = append(tasks, task)
+		event.TaskIDs = append(event.TaskIDs, task.TaskID)
+	}
+
+	// Conditional, so a re-run keeps fallback tasks appended since
+	if created, err = st.CreateEvent(ctx, event); err != nil {
+		return false, err
+	}
+	for _, task := range tasks {
+		if _, err := st.CreateTask(ctx, task); err != nil {
+			return created, err
+		}
+	}
+	for _, task := range tasks {
+		if err := lanes.PublishTask(ctx, task.TaskID, task.Priority); err != nil {
+			return created, err
+		}
+	}
+	return created, nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	kafkaproducer "safe-notify/internal/queue"
+	"safe-notify/internal/store"
+	"safe-notify/internal/tenant"
+)
+
+// releaseScheduled polls Dynamo for SCHEDULED tasks whose send_at has passed
+// and moves them onto the main topic, then fires due recurring schedules and
+// closes finished digest windows. Scheduled sends live in Dynamo rather
+// than the retry topic because sleeping on a days-ahead message would block
+// every retry behind it, and Dynamo state survives restarts.
+func releaseScheduled(ctx context.Context, st *store.DynamoStore, lanes *kafkaproducer.LaneProducer, tenants tenant.Registry, every time.Duration) {
+	t := time.NewTicker(every)
+	defer t.Stop()
+
+	for {
+		if err := releaseDue(ctx, st, lanes); err != nil {
+			log.Println("scheduler: release scheduled:", err)
+		}
+		if err := fireDueSchedules(ctx, st, lanes, tenants, time.Now().UnixMilli()); err != nil {
+			log.Println("scheduler: recurring schedules:", err)
+		}
+		if err := dispatchDueDigests(ctx, st, lanes, tenants, time.Now().UnixMilli()); err != nil {
+			log.Println("scheduler: digests:", err)
+		}
+		if err := republishStalledWebhooks(ctx, st, lanes); err != nil {
+			log.Println("scheduler: stalled webhooks:", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+func releaseDue(ctx context.Context, st *store.DynamoStore, lanes *kafkaproducer.LaneProducer) error {
+	now := time.Now().UnixMilli()
+	tasks, err := st.FetchDueScheduledTasks(ctx, now, 500)
+	if err != nil {
+		return err
+	}
+
+	for _, task := range tasks {
+		// Conditional: a task cancelled since the scan stays cancelled,
+		// and two schedulers can't both release it.
+		ok, err := st.TransitionStatus(ctx, task.TaskID, []string{"SCHEDULED"}, "PENDING", now)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+
+		if err := lanes.PublishTask(ctx, task.TaskID, task.Priority); err != nil {
+			// Put it back so the next poll retries the publish
+			if _, rerr := st.TransitionStatus(ctx, task.TaskID, []string{"PENDING"}, "SCHEDULED", time.Now().UnixMilli()); rerr != nil {
+				log.Println("scheduler: revert release failed:", task.TaskID, rerr)
+			}
+			return err
+		}
+		log.Println("scheduler: released scheduled task", task.TaskID)
+	}
+	return nil
+}
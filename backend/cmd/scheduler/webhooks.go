@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	kafkaproducer "safe-notify/internal/queue"
+	"safe-notify/internal/store"
+)
+
+// webhookStallAfter is how long a stored delivery may stay PENDING, or
+// FAILED past its retry time, before the scheduler assumes its Kafka
+// message was never published.
+const webhookStallAfter = 2 * time.Minute
+
+// webhookClaimTimeout is how long a delivery may stay PROCESSING before the
+// scheduler assumes its worker died mid-attempt. It is well above the
+// worker's HTTP timeout, so live attempts aren't handed out twice.
+const webhookClaimTimeout = 5 * time.Minute
+
+// republishStalledWebhooks re-queues deliveries a worker stored but never
+// published (see the worker's queueCallbacks), lost mid-attempt, or whose
+// retry was never published. Touching updated_at first
+// keeps a lagging queue from getting a fresh copy on every poll.
+func republishStalledWebhooks(ctx context.Context, st *store.DynamoStore, lanes *kafkaproducer.LaneProducer) error {
+	now := time.Now()
+	stalled, err := st.FetchStalledWebhookDeliveries(ctx, now.Add(-webhookStallAfter).UnixMilli(), now.Add(-webhookClaimTimeout).UnixMilli(), 500)
+	if err != nil {
+		return err
+	}
+	for _, d := range stalled {
+		ok, err := st.TouchWebhookDelivery(ctx, d, now.UnixMilli())
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		if err := lanes.Main().PublishWebhook(ctx, d.DeliveryID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"safe-notify/internal/models"
+)
+
+// escalate creates the next task in the fallback chain of a task that is
+// about to go to DLQ. The new task ID is derived from the failed one, so a
+// redelivered message can't create a second copy.
+func (w *worker) escalate(ctx context.Context, task models.Task) error {
+	step := task.Fallback[0]
+	now := time.Now().UnixMilli()
+
+	next := models.Task{
+		TaskID:           task.TaskID + "_fb",
+		IdempotencyKey:   fmt.Sprintf("%s:%s:%s:%s:fallback", task.EventType, task.EntityID, step.Channel, step.Recipient),
+		EventID:          task.EventID,
+		TenantID:         task.TenantID,
+		EventType:        task.EventType,
+		EntityID:         task.EntityID,
+		Channel:          step.Channel,
+		Recipient:        step.Recipient,
+		Priority:         task.Priority,
+		CallbackURL:      task.CallbackURL,
+		Fallback:         task.Fallback[1:],
+		FallbackOf:       task.TaskID,
+		Status:           "PENDING",
+		MaxAttempts:      task.MaxAttempts,
+		ChaosFailPercent: task.ChaosFailPercent,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+	if next.Channel == "EMAIL" {
+		next.RecipientEmail = next.Recipient
+	}
+	if step.DelaySeconds > 0 {
+		next.NextRetryAt = now + int64(step.DelaySeconds)*1000
+	}
+
+	created, err := w.st.CreateTask(ctx, next)
+	if err != nil {
+		return err
+	}
+	if !created {
+		// Escalated on an earlier delivery of this message; make sure it is queued
+		log.Println("worker: fallback already exists:", next.TaskID)
+	}
+
+	if next.EventID != "" {
+		if err := w.st.AppendEventTask(ctx, next.EventID, next.TaskID); err != nil {
+			return err
+		}
+	}
+
+	// Delayed steps wait in the retry topic like any other retry
+	if next.NextRetryAt > 0 {
+		return w.retryProducer.PublishRetry(ctx, next.TaskID, next.NextRetryAt)
+	}
+	return w.lanes.PublishTask(ctx, next.TaskID, next.Priority)
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"log"
+	"time"
+
+	kafkaproducer "safe-notify/internal/queue"
+)
+
+// reportLanes logs per-lane lag every interval.
+func reportLanes(lc *kafkaproducer.LaneConsumer, every time.Duration) {
+	if every <= 0 {
+		return
+	}
+	for range time.Tick(every) {
+		for _, s := range lc.Stats() {
+			log.Println("worker: lane", s.Priority, "topic=", s.Topic, "lag=", s.Lag, "consumed=", s.Consumed)
+		}
+	}
+}
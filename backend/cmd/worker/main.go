@@ -2,19 +2,47 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"log"
+	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/joho/godotenv"
 
+	"safe-notify/internal/breaker"
+	"safe-notify/internal/channel"
 	"safe-notify/internal/email"
+	"safe-notify/internal/models"
 	kafkaproducer "safe-notify/internal/queue"
+	"safe-notify/internal/ratelimit"
 	"safe-notify/internal/store"
+	"safe-notify/internal/tenant"
+	"safe-notify/internal/unsubscribe"
+	"safe-notify/internal/webhook"
 )
 
+// worker bundles the dependencies processOne needs.
+type worker struct {
+	id            string
+	st            *store.DynamoStore
+	channels      *channel.Dispatcher
+	retryProducer *kafkaproducer.Producer     // safe-notify-retry
+	mainProducer  *kafkaproducer.Producer     // safe-notify-tasks (webhook deliveries)
+	lanes         *kafkaproducer.LaneProducer // priority lanes (fallback tasks)
+	hooks         webhookStore                // st, narrowed for webhook deliveries
+	hookRetries   webhookRetrier              // retryProducer, narrowed for webhook deliveries
+	hookSecret    string                      // signs per-event callbackUrl POSTs
+	httpClient    *http.Client
+	limiter       *ratelimit.Limiter          // nil = no outbound rate limits
+	maxLimitWait  time.Duration               // longer rate-limit waits requeue instead of sleeping
+	breakers      map[string]*breaker.Breaker // channel -> circuit breaker
+	tenants       tenant.Registry             // per-tenant retry policy (and sender identity, via channels)
+}
+
 func main() {
 	_ = godotenv.Load()
 	ctx := context.Background()
@@ -37,20 +65,152 @@ func main() {
 		log.Fatal("worker: init ses:", err)
 	}
 
+	tenants, err := tenant.Load()
+	if err != nil {
+		log.Fatal("worker: ", err)
+	}
+
+	// Unsubscribe links (optional)
+	var unsub *unsubscribe.Signer
+	if secret := os.Getenv("UNSUBSCRIBE_SECRET"); secret != "" {
+		unsub = unsubscribe.NewSigner(secret, os.Getenv("PUBLIC_API_URL"))
+	}
+
+	// Channel adapters (EMAIL and IN_APP always, others when configured)
+	channels := channel.NewDispatcher()
+	channels.Register("EMAIL", &channel.Email{Sender: sender, Unsub: unsub, Tenants: tenants})
+	channels.Register("IN_APP", &channel.InApp{Inbox: st})
+	if url := os.Getenv("WEBHOOK_CHANNEL_URL"); url != "" {
+		headers := map[string]string{}
+		if raw := os.Getenv("WEBHOOK_CHANNEL_HEADERS"); raw != "" {
+			if err := json.Unmarshal([]byte(raw), &headers); err != nil {
+				log.Fatal("worker: WEBHOOK_CHANNEL_HEADERS must be a JSON object:", err)
+			}
+		}
+		timeout := time.Duration(getenvInt("WEBHOOK_CHANNEL_TIMEOUT_MS", 5000)) * time.Millisecond
+		channels.Register("WEBHOOK", channel.NewWebhook(url, os.Getenv("WEBHOOK_CHANNEL_SECRET"), headers, timeout))
+	}
+
+	slackChannels := map[string]string{}
+	if raw := os.Getenv("SLACK_CHANNELS"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &slackChannels); err != nil {
+			log.Fatal("worker: SLACK_CHANNELS must be a JSON object:", err)
+		}
+	}
+	if def := os.Getenv("SLACK_DEFAULT_CHANNEL"); def != "" || len(slackChannels) > 0 {
+		channels.Register("SLACK", channel.NewSlack(
+			os.Getenv("SLACK_API_URL"),
+			os.Getenv("SLACK_BOT_TOKEN"),
+			def,
+			slackChannels,
+			os.Getenv("SLACK_TEMPLATES_DIR"),
+		))
+	}
+
+	switch os.Getenv("SMS_PROVIDER") {
+	case "twilio":
+		channels.Register("SMS", &channel.SMS{
+			Provider: channel.NewTwilioSMS(
+				os.Getenv("TWILIO_API_URL"),
+				os.Getenv("TWILIO_ACCOUNT_SID"),
+				os.Getenv("TWILIO_AUTH_TOKEN"),
+				os.Getenv("TWILIO_FROM"),
+			),
+			MaxSegments: getenvInt("SMS_MAX_SEGMENTS", 3),
+		})
+	case "fake":
+		channels.Register("SMS", &channel.SMS{Provider: &channel.FakeSMS{}, MaxSegments: getenvInt("SMS_MAX_SEGMENTS", 3)})
+	}
+
+	if project := os.Getenv("PUSH_PROJECT_ID"); project != "" {
+		channels.Register("PUSH", channel.NewPush(st, os.Getenv("PUSH_API_URL"), project, os.Getenv("PUSH_AUTH_TOKEN")))
+	}
+
 	// Kafka config
 	brokersCSV := getenv("KAFKA_BROKERS", "localhost:9092")
 	mainTopic := getenv("KAFKA_TOPIC_MAIN", "safe-notify-tasks")
 	retryTopic := getenv("KAFKA_TOPIC_RETRY", "safe-notify-retry")
 	groupID := getenv("KAFKA_GROUP_ID", "safe-notify-workers")
 
-	// Consume main topic (work queue)
-	mainConsumer := kafkaproducer.NewConsumer(splitCSV(brokersCSV), mainTopic, groupID)
+	// Consume every priority lane (work queue), weighted towards HIGH
+	weights := map[string]int{}
+	if raw := os.Getenv("LANE_WEIGHTS"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &weights); err != nil {
+			log.Fatal("worker: LANE_WEIGHTS must be a JSON object of priority -> weight:", err)
+		}
+	}
+	mainConsumer := kafkaproducer.NewLaneConsumer(ctx, splitCSV(brokersCSV), mainTopic, groupID, weights)
 	defer mainConsumer.Close()
+	go reportLanes(mainConsumer, time.Duration(getenvInt("LANE_STATS_LOG_MS", 60000))*time.Millisecond)
 
 	// Produce retry messages (delayed retry queue)
 	retryProducer := kafkaproducer.NewProducer(brokersCSV, retryTopic)
 	defer retryProducer.Close()
 
+	// Produce fallback tasks onto their lane, webhook deliveries onto the main topic
+	lanes := kafkaproducer.NewLaneProducer(brokersCSV, mainTopic)
+	defer lanes.Close()
+
+	// callbackUrl deliveries are signed with this; without it they go to DLQ
+	// rather than out unsigned (subscriptions have their own secrets)
+	hookSecret := os.Getenv("WEBHOOK_SIGNING_SECRET")
+	if hookSecret == "" {
+		log.Println("worker: WARNING: WEBHOOK_SIGNING_SECRET is not set; callbackUrl deliveries will go to DLQ")
+	}
+
+	w := &worker{
+		id:            workerID,
+		st:            st,
+		channels:      channels,
+		retryProducer: retryProducer,
+		mainProducer:  lanes.Main(),
+		lanes:         lanes,
+		hooks:         st,
+		hookRetries:   retryProducer,
+		hookSecret:    hookSecret,
+		httpClient:    webhook.NewClient(10*time.Second, os.Getenv("WEBHOOK_ALLOW_PRIVATE") == "true"),
+		maxLimitWait:  time.Duration(getenvInt("RATE_LIMIT_MAX_WAIT_MS", 1000)) * time.Millisecond,
+		tenants:       tenants,
+	}
+	// Circuit breakers around the providers of the listed channels
+	breakerCfg := breaker.Config{
+		FailureRate: float64(getenvInt("BREAKER_FAILURE_PERCENT", 50)) / 100,
+		MinRequests: getenvInt("BREAKER_MIN_REQUESTS", 10),
+		Window:      time.Duration(getenvInt("BREAKER_WINDOW_MS", 60000)) * time.Millisecond,
+		OpenFor:     time.Duration(getenvInt("BREAKER_OPEN_MS", 30000)) * time.Millisecond,
+	}
+	w.breakers = map[string]*breaker.Breaker{}
+	for _, ch := range splitCSV(getenv("BREAKER_CHANNELS", "EMAIL")) {
+		b := breaker.New(strings.ToUpper(ch), breakerCfg)
+		b.OnStateChange = func(name string, from, to breaker.State) {
+			log.Println("worker: circuit breaker", name, from, "->", to)
+		}
+		w.breakers[strings.ToUpper(ch)] = b
+	}
+	if addr := os.Getenv("WORKER_METRICS_ADDR"); addr != "" {
+		go serveMetrics(addr, mainConsumer, w.breakers)
+	}
+
+	rules := map[string]ratelimit.Rule{}
+	if raw := os.Getenv("RATE_LIMITS"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &rules); err != nil {
+			log.Fatal("worker: RATE_LIMITS must be a JSON object of scope -> {rate, burst}:", err)
+		}
+	}
+	tenantDomains := map[string]string{}
+	for id, cfg := range tenants {
+		if cfg.RateLimit != nil {
+			rules["tenant:"+id] = *cfg.RateLimit
+		}
+		if _, d, ok := strings.Cut(cfg.FromEmail, "@"); ok {
+			tenantDomains[id] = d
+		}
+	}
+	if len(rules) > 0 {
+		_, domain, _ := strings.Cut(os.Getenv("SES_FROM_EMAIL"), "@")
+		w.limiter = &ratelimit.Limiter{Buckets: st, Rules: rules, SendingDomain: domain, TenantDomains: tenantDomains}
+	}
+
 	log.Println("worker: started",
 		"workerID=", workerID,
 		"mainTopic=", mainTopic,
@@ -59,7 +219,7 @@ func main() {
 	)
 
 	for {
-		// 1) Read one task message from the MAIN topic
+		// 1) Read one task message from the next priority lane
 		tm, commit, err := mainConsumer.ReadTask(ctx)
 		if err != nil {
 			log.Println("worker: read error:", err)
@@ -67,8 +227,12 @@ func main() {
 			continue
 		}
 
-		// 2) Process the task
-		if err := processOne(ctx, st, sender, workerID, tm.TaskID, retryProducer); err != nil {
+		// 2) Process the task (or webhook delivery)
+		process := w.processOne
+		if tm.Kind == kafkaproducer.KindWebhook {
+			process = w.processWebhook
+		}
+		if err := process(ctx, tm.TaskID); err != nil {
 			log.Println("worker: process error:", err)
 			// IMPORTANT: if system fails BEFORE scheduling retry, DO NOT commit.
 			// Kafka will redeliver this same message later.
@@ -83,13 +247,9 @@ func main() {
 	}
 }
 
-func processOne(
-	ctx context.Context,
-	st *store.DynamoStore,
-	sender email.Sender,
-	workerID, taskID string,
-	retryProducer *kafkaproducer.Producer,
-) error {
+func (w *worker) processOne(ctx context.Context, taskID string) error {
+	st := w.st
+
 	// Load full task from Dynamo (truth)
 	task, err := st.GetTaskByID(ctx, taskID)
 	if err != nil {
@@ -99,6 +259,10 @@ func processOne(
 		// Task missing/deleted: nothing to do; safe to commit Kafka message
 		return nil
 	}
+	if models.IsTerminal(task.Status) {
+		// Cancelled (or already finished) while the message was in flight
+		return nil
+	}
 
 	// OPTIONAL SAFETY:
 	// If retry was scheduled, don't process before NextRetryAt
@@ -109,8 +273,24 @@ func processOne(
 		return nil
 	}
 
+	// Look up suppressions and preferences before claiming, so a failed
+	// lookup leaves the task PENDING for the redelivery. Tasks without a
+	// recipient (WEBHOOK, Slack's default channel) have neither.
+	var (
+		sup  *models.Suppression
+		pref *models.Preference
+	)
+	if to := strings.ToLower(task.To()); to != "" {
+		if sup, err = st.GetSuppression(ctx, to); err != nil {
+			return err
+		}
+		if pref, err = st.GetPreference(ctx, task.TenantID, to); err != nil {
+			return err
+		}
+	}
+
 	// Claim the task so only this worker can process it (prevents double-send of messages)
-	claimed, err := st.ClaimTask(ctx, task.TaskID, workerID, now)
+	claimed, err := st.ClaimTask(ctx, task.TaskID, w.id, now)
 	if err != nil {
 		return err
 	}
@@ -119,15 +299,77 @@ func processOne(
 		return nil
 	}
 
-	// Attempt delivery (chaos + SES)
-	ok, errMsg := attemptSend(ctx, sender, *task)
+	if err := w.deliver(ctx, *task, sup, pref, now); err != nil {
+		w.release(ctx, task.TaskID, err)
+		return err
+	}
+	return nil
+}
+
+// deliver runs a claimed task through suppression, quiet hours, rate limits
+// and the provider, and records the outcome.
+func (w *worker) deliver(ctx context.Context, task models.Task, sup *models.Suppression, pref *models.Preference, now int64) error {
+	st := w.st
+	if pref != nil {
+		task.Locale = pref.Locale
+	}
+
+	// Never send to bounced/complained addresses
+	if sup != nil && sup.Active(now) {
+		return w.finish(ctx, task, "SUPPRESSED", task.AttemptCount, "address suppressed: "+sup.Reason)
+	}
+
+	// Respect recipient preferences before spending an attempt
+	if pref != nil && !pref.Allows(task.EventType, task.Channel) {
+		return w.finish(ctx, task, "SUPPRESSED", task.AttemptCount, "recipient opted out of "+models.SubscriptionKey(task.EventType, task.Channel))
+	}
+
+	// Hold non-HIGH tasks until the recipient's quiet hours end. They go back
+	// through the scheduler's SCHEDULED poll rather than the retry topic, where
+	// an hours-long wait would hold up every retry queued behind it.
+	if pref != nil && task.Priority != "HIGH" {
+		if until, quiet := pref.QuietUntil(time.Now()); quiet {
+			return st.DeferTask(ctx, task.TaskID, until.UnixMilli(), "deferred: quiet hours until "+until.Format(time.RFC3339), time.Now().UnixMilli())
+		}
+	}
+
+	// Wait for provider capacity; a rate limit is not a failed attempt
+	if w.limiter != nil {
+		requeued, err := w.awaitCapacity(ctx, task)
+		if err != nil || requeued {
+			return err
+		}
+	}
+
+	// While the provider's breaker is open, reschedule without spending an
+	// attempt, and give back the rate-limit tokens the send would have used.
+	// Checked last so a half-open probe always reaches Record.
+	brk := w.breakers[task.Channel]
+	if brk != nil {
+		if ok, retryIn := brk.Allow(); !ok {
+			if w.limiter != nil {
+				if err := w.limiter.Release(ctx, task, models.TenantOrDefault(task.TenantID)); err != nil {
+					log.Println("worker: return rate-limit tokens failed:", task.TaskID, err)
+				}
+			}
+			return w.requeue(ctx, task, retryIn, "circuit open: "+task.Channel)
+		}
+	}
+
+	// Attempt delivery (chaos + channel adapter)
+	sendErr := attemptSend(ctx, w.channels, task)
+	if brk != nil {
+		// Permanent errors are about the task (bad address), not the provider
+		brk.Record(sendErr != nil && !channel.IsPermanent(sendErr))
+	}
 
 	newAttempt := task.AttemptCount + 1
 
 	// Success path
-	if ok {
-		return st.UpdateAfterAttempt(ctx, task.TaskID, "SENT", newAttempt, "", time.Now().UnixMilli())
+	if sendErr == nil {
+		return w.finish(ctx, task, "SENT", newAttempt, "")
 	}
+	errMsg := sendErr.Error()
 
 	// Failure path
 	max := task.MaxAttempts
@@ -136,26 +378,131 @@ func processOne(
 	}
 
 	// Terminal failure => DLQ state in Dynamo (NO Kafka DLQ topic)
-	if newAttempt >= max {
-		return st.UpdateAfterAttempt(ctx, task.TaskID, "DLQ", newAttempt, errMsg, time.Now().UnixMilli())
+	// Permanent errors (bad URL, 4xx from provider) skip the remaining attempts.
+	if newAttempt >= max || channel.IsPermanent(sendErr) {
+		return w.finish(ctx, task, "DLQ", newAttempt, errMsg)
 	}
 
 	// Not terminal => schedule retry via retry topic
-	backoff := computeBackoffMs(newAttempt)         // e.g. attempt1=2s, attempt2=5s
+	backoff, ok := w.tenants.Get(task.TenantID).Backoff(newAttempt)
+	if !ok {
+		backoff = computeBackoffMs(newAttempt) // e.g. attempt1=2s, attempt2=5s
+	}
+	if d, ok := channel.RetryDelay(sendErr); ok && d.Milliseconds() > backoff {
+		backoff = d.Milliseconds() // provider asked us to wait longer
+	}
 	nextRetryAt := time.Now().UnixMilli() + backoff // epoch ms
 
+	failed := task
+	failed.AttemptCount, failed.LastError, failed.NextRetryAt = newAttempt, errMsg, nextRetryAt
+	callbacks, err := w.queueCallbacks(ctx, failed, "FAILED")
+	if err != nil {
+		return err
+	}
+
 	// Update Dynamo so UI shows FAILED + next_retry_at
 	if err := st.UpdateForRetry(ctx, task.TaskID, newAttempt, errMsg, nextRetryAt, time.Now().UnixMilli()); err != nil {
 		return err
 	}
 
 	// Publish retry message so scheduler can re-enqueue later
-	if err := retryProducer.PublishRetry(ctx, task.TaskID, nextRetryAt); err != nil {
+	if err := w.retryProducer.PublishRetry(ctx, task.TaskID, nextRetryAt); err != nil {
 		// If Kafka publish fails, return error so we DON'T commit.
 		// Kafka will redeliver the main message and we'll try scheduling again.
+		// Clear next_retry_at first, or that redelivery would skip it as early.
+		if rerr := st.UpdateForRetry(ctx, task.TaskID, newAttempt, errMsg, 0, time.Now().UnixMilli()); rerr != nil {
+			log.Println("worker: clear retry time failed:", task.TaskID, rerr)
+		}
+		return err
+	}
+
+	w.meter(ctx, failed, "FAILED")
+	w.publishCallbacks(ctx, callbacks)
+	return nil
+}
+
+// release hands a claimed task back after an error part-way through
+// processing, so the redelivered Kafka message can claim it again instead of
+// finding it stuck in PROCESSING. A task that was sent but whose SENT write
+// failed is sent again; webhook receivers get the same Idempotency-Key.
+func (w *worker) release(ctx context.Context, taskID string, cause error) {
+	ok, err := w.st.ReleaseTask(ctx, taskID, w.id, "released: "+cause.Error(), time.Now().UnixMilli())
+	if err != nil {
+		log.Println("worker: release claim failed:", taskID, err)
+		return
+	}
+	if ok {
+		log.Println("worker: released claim on", taskID, "after:", cause)
+	}
+}
+
+// finish records a terminal status and tells any webhook receivers about it.
+// A DLQ task with a fallback chain escalates to the next step first, so a
+// failed escalation leaves the Kafka message uncommitted.
+func (w *worker) finish(ctx context.Context, task models.Task, status string, attemptCount int, lastError string) error {
+	if status == "DLQ" && len(task.Fallback) > 0 {
+		if err := w.escalate(ctx, task); err != nil {
+			return err
+		}
+	}
+	// Members before the digest itself: once the digest is terminal a
+	// redelivered message is dropped, and nothing would finish them.
+	if len(task.Digest) > 0 {
+		if err := w.finishDigestMembers(ctx, task, status, lastError); err != nil {
+			return err
+		}
+	}
+
+	task.AttemptCount, task.LastError, task.NextRetryAt = attemptCount, lastError, 0
+	callbacks, err := w.queueCallbacks(ctx, task, status)
+	if err != nil {
+		return err
+	}
+	if err := w.st.UpdateAfterAttempt(ctx, task.TaskID, status, attemptCount, lastError, time.Now().UnixMilli()); err != nil {
 		return err
 	}
+	w.meter(ctx, task, status)
+	w.publishCallbacks(ctx, callbacks)
+	return nil
+}
+
+// meter counts a delivery outcome in the tenant's daily and monthly usage.
+// Digest members aren't metered; the digest delivery itself is. Metering is
+// best effort: a failed update is logged, not retried.
+func (w *worker) meter(ctx context.Context, task models.Task, status string) {
+	now := time.Now()
+	if err := w.st.RecordUsage(ctx, task.TenantID, task.Channel, status, models.UsageDay(now), models.UsageMonth(now)); err != nil {
+		log.Println("worker: record usage failed:", task.TaskID, err)
+	}
+}
 
+// finishDigestMembers gives the tasks a digest carried the digest's outcome,
+// so their events and callbacks resolve like individually sent tasks.
+func (w *worker) finishDigestMembers(ctx context.Context, digest models.Task, status, lastError string) error {
+	ids := make([]string, 0, len(digest.Digest))
+	for _, e := range digest.Digest {
+		ids = append(ids, e.TaskID)
+	}
+	members, err := w.st.GetTasksByIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UnixMilli()
+	for _, m := range members {
+		if m.Status != "BATCHED" {
+			continue // cancelled, or already finished on an earlier delivery
+		}
+		m.LastError = lastError
+		callbacks, err := w.queueCallbacks(ctx, m, status)
+		if err != nil {
+			return err
+		}
+		if err := w.st.UpdateAfterAttempt(ctx, m.TaskID, status, 0, lastError, now); err != nil {
+			return err
+		}
+		w.publishCallbacks(ctx, callbacks)
+	}
 	return nil
 }
 
@@ -184,6 +531,14 @@ func splitCSV(s string) []string {
 	return out
 }
 
+func getenvInt(k string, def int) int {
+	v, err := strconv.Atoi(os.Getenv(k))
+	if err != nil {
+		return def
+	}
+	return v
+}
+
 func getenv(k, def string) string {
 	v := os.Getenv(k)
 	if v == "" {
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+
+	"safe-notify/internal/breaker"
+	kafkaproducer "safe-notify/internal/queue"
+)
+
+// breakerStateValue encodes breaker states for the metrics gauge.
+var breakerStateValue = map[breaker.State]int{breaker.Closed: 0, breaker.HalfOpen: 1, breaker.Open: 2}
+
+// serveMetrics exposes lane lag and circuit breaker state:
+//
+//	GET /lanes     per-lane lag (JSON)
+//	GET /breakers  per-channel breaker state (JSON)
+//	GET /metrics   both, in Prometheus text format
+func serveMetrics(addr string, lc *kafkaproducer.LaneConsumer, breakers map[string]*breaker.Breaker) {
+	snapshots := func() []breaker.Snapshot {
+		out := make([]breaker.Snapshot, 0, len(breakers))
+		for _, b := range breakers {
+			out = append(out, b.Snapshot())
+		}
+		sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+		return out
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /lanes", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"lanes": lc.Stats()})
+	})
+	mux.HandleFunc("GET /breakers", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"breakers": snapshots()})
+	})
+	mux.HandleFunc("GET /metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		for _, s := range lc.Stats() {
+			fmt.Fprintf(w, "safe_notify_lane_lag{priority=%q,topic=%q} %d\n", s.Priority, s.Topic, s.Lag)
+			fmt.Fprintf(w, "safe_notify_lane_consumed_total{priority=%q} %d\n", s.Priority, s.Consumed)
+		}
+		for _, s := range snapshots() {
+			fmt.Fprintf(w, "safe_notify_breaker_state{channel=%q} %d\n", s.Name, breakerStateValue[s.State])
+			fmt.Fprintf(w, "safe_notify_breaker_failure_rate{channel=%q} %g\n", s.Name, s.FailureRate)
+			fmt.Fprintf(w, "safe_notify_breaker_trips_total{channel=%q} %d\n", s.Name, s.Trips)
+		}
+	})
+
+	log.Println("worker: metrics on", addr)
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		log.Println("worker: metrics server:", err)
+	}
+}
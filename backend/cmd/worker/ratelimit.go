@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"safe-notify/internal/models"
+)
+
+// awaitCapacity blocks for short rate-limit waits, or until ctx is done. For
+// longer ones it hands the task back through the retry topic with its
+// attempt count unchanged and reports requeued=true.
+func (w *worker) awaitCapacity(ctx context.Context, task models.Task) (bool, error) {
+	for {
+		wait, key, err := w.limiter.Reserve(ctx, task, models.TenantOrDefault(task.TenantID))
+		if err != nil {
+			return false, err
+		}
+		if wait == 0 {
+			return false, nil
+		}
+		if wait <= w.maxLimitWait {
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				return false, ctx.Err()
+			}
+			continue
+		}
+
+		return true, w.requeue(ctx, task, wait, "rate limited: "+key)
+	}
+}
+
+// requeue hands a claimed task back through the retry topic after delay
+// without counting an attempt.
+func (w *worker) requeue(ctx context.Context, task models.Task, delay time.Duration, reason string) error {
+	nextRetryAt := time.Now().Add(delay).UnixMilli()
+	if err := w.st.RequeueTask(ctx, task.TaskID, nextRetryAt, reason, time.Now().UnixMilli()); err != nil {
+		return err
+	}
+	if err := w.retryProducer.PublishRetry(ctx, task.TaskID, nextRetryAt); err != nil {
+		// Due now, so the redelivered main message picks it up again
+		if rerr := w.st.RequeueTask(ctx, task.TaskID, 0, reason, time.Now().UnixMilli()); rerr != nil {
+			log.Println("worker: clear retry time failed:", task.TaskID, rerr)
+		}
+		return err
+	}
+	return nil
+}
@@ -2,20 +2,20 @@ package main
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"math/rand"
 	"time"
 
-	"safe-notify/internal/email"
+	"safe-notify/internal/channel"
 	"safe-notify/internal/models"
 )
 
-// attemptSend returns:
-// - ok = true if delivery succeeded
-// - ok = false if delivery failed, with an error message
+// attemptSend returns nil if delivery succeeded, otherwise the failure.
+// channel.Permanent / channel.RetryAfter errors tell processOne how to retry.
 //
-// It first applies chaos injection (demo), then sends a real email via AWS SES.
-func attemptSend(ctx context.Context, sender email.Sender, task models.Task) (bool, string) {
+// It first applies chaos injection (demo), then hands the task to the
+// channel adapter registered for task.Channel (SES for EMAIL, etc).
+func attemptSend(ctx context.Context, channels *channel.Dispatcher, task models.Task) error {
 	// Chaos simulation (demo feature)
 	p := task.ChaosFailPercent
 	if p < 0 {
@@ -27,19 +27,8 @@ func attemptSend(ctx context.Context, sender email.Sender, task models.Task) (bo
 
 	r := rand.New(rand.NewSource(time.Now().UnixNano()))
 	if r.Intn(100) < p {
-		return false, "CHAOS injected failure"
+		return errors.New("CHAOS injected failure")
 	}
 
-	// Real email via SES
-	subject := fmt.Sprintf("[Safe-Notify] %s (%s)", task.EventType, task.EntityID)
-	body := fmt.Sprintf(
-		"TaskID: %s\nEventType: %s\nEntityID: %s\nPriority: %s\nChannel: %s\n",
-		task.TaskID, task.EventType, task.EntityID, task.Priority, task.Channel,
-	)
-
-	if err := sender.Send(ctx, task.RecipientEmail, subject, body); err != nil {
-		return false, "SES send failed: " + err.Error()
-	}
-
-	return true, ""
+	return channels.Deliver(ctx, task)
 }
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"safe-notify/internal/models"
+	"safe-notify/internal/webhook"
+)
+
+const webhookMaxAttempts = 6
+
+// webhookStore is the part of the store processWebhook needs.
+type webhookStore interface {
+	GetWebhookDelivery(ctx context.Context, deliveryID string) (*models.WebhookDelivery, error)
+	ClaimWebhookDelivery(ctx context.Context, deliveryID string, nowMs int64) (bool, error)
+	ReleaseWebhookDelivery(ctx context.Context, deliveryID, reason string, nowMs int64) (bool, error)
+	UpdateWebhookDelivery(ctx context.Context, deliveryID, newStatus string, attemptCount int, lastError string, nextRetryAt, nowMs int64) error
+	GetWebhookSubscription(ctx context.Context, subscriptionID string) (*models.WebhookSubscription, error)
+}
+
+// webhookRetrier schedules the next attempt of a delivery.
+type webhookRetrier interface {
+	PublishWebhookRetry(ctx context.Context, deliveryID string, nextRetryAt int64) error
+}
+
+// queueCallbacks stores a PENDING signed status callback for the task's
+// callbackUrl and every matching subscription, and returns their IDs for
+// publishCallbacks. Call it before writing the status: if the worker dies
+// in between, the deliveries are already stored and the scheduler
+// republishes them. IDs are deterministic, so a re-run of the same
+// transition finds the existing deliveries instead of adding more.
+func (w *worker) queueCallbacks(ctx context.Context, task models.Task, status string) ([]string, error) {
+	now := time.Now().UnixMilli()
+
+	body, err := json.Marshal(models.TaskStatusEvent{
+		Event:          "task.status_changed",
+		TaskID:         task.TaskID,
+		IdempotencyKey: task.IdempotencyKey,
+		EventType:      task.EventType,
+		EntityID:       task.EntityID,
+		Channel:        task.Channel,
+		Recipient:      task.To(),
+		Status:         status,
+		AttemptCount:   task.AttemptCount,
+		LastError:      task.LastError,
+		NextRetryAt:    task.NextRetryAt,
+		Timestamp:      now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var targets []models.WebhookDelivery
+	if task.CallbackURL != "" {
+		targets = append(targets, models.WebhookDelivery{URL: task.CallbackURL})
+	}
+
+	subs, err := w.st.ListWebhookSubscriptions(ctx, task.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	for _, sub := range subs {
+		if sub.Matches(task.EventType, status) {
+			targets = append(targets, models.WebhookDelivery{URL: sub.URL, SubscriptionID: sub.SubscriptionID})
+		}
+	}
+
+	ids := make([]string, 0, len(targets))
+	for _, d := range targets {
+		target := d.SubscriptionID
+		if target == "" {
+			target = "callback"
+		}
+		d.DeliveryID = fmt.Sprintf("whd_%s_%s_%d_%s", task.TaskID, status, task.AttemptCount, target)
+		d.TaskID = task.TaskID
+		d.Payload = string(body)
+		d.Status = "PENDING"
+		d.MaxAttempts = webhookMaxAttempts
+		d.CreatedAt = now
+		d.UpdatedAt = now
+
+		// An existing delivery is still published: the run that created it
+		// may have died before publishing
+		if _, err := w.st.CreateWebhookDelivery(ctx, d); err != nil {
+			return nil, err
+		}
+		ids = append(ids, d.DeliveryID)
+	}
+	return ids, nil
+}
+
+// publishCallbacks queues deliveries stored by queueCallbacks once the
+// status they report has been written. Failures are only logged: the
+// scheduler republishes deliveries left PENDING, and a duplicate message
+// loses the delivery's claim.
+func (w *worker) publishCallbacks(ctx context.Context, ids []string) {
+	for _, id := range ids {
+		if err := w.mainProducer.PublishWebhook(ctx, id); err != nil {
+			log.Println("worker: publish webhook delivery:", err)
+		}
+	}
+}
+
+// processWebhook performs one attempt of a webhook delivery and schedules
+// the next one through the retry topic, exactly like a task. Any error after
+// the claim hands the delivery back, so the redelivered message can claim it.
+func (w *worker) processWebhook(ctx context.Context, deliveryID string) error {
+	d, err := w.hooks.GetWebhookDelivery(ctx, deliveryID)
+	if err != nil {
+		return err
+	}
+	if d == nil {
+		return nil
+	}
+
+	now := time.Now().UnixMilli()
+	if d.NextRetryAt > 0 && now < d.NextRetryAt {
+		return nil
+	}
+
+	claimed, err := w.hooks.ClaimWebhookDelivery(ctx, d.DeliveryID, now)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return nil
+	}
+
+	secret := w.hookSecret
+	if d.SubscriptionID == "" && secret == "" {
+		return w.updateWebhook(ctx, d.DeliveryID, "DLQ", d.AttemptCount, "signing secret not configured", 0)
+	}
+	if d.SubscriptionID != "" {
+		sub, err := w.hooks.GetWebhookSubscription(ctx, d.SubscriptionID)
+		if err != nil {
+			return w.releaseWebhook(ctx, d.DeliveryID, err)
+		}
+		if sub == nil {
+			// Subscription was deleted while the delivery was pending
+			return w.updateWebhook(ctx, d.DeliveryID, "DLQ", d.AttemptCount, "subscription deleted", 0)
+		}
+		secret = sub.Secret
+	}
+
+	code, _, err := webhook.Post(ctx, w.httpClient, webhook.Request{
+		URL:    d.URL,
+		Body:   []byte(d.Payload),
+		Secret: secret,
+	})
+	errMsg := ""
+	switch {
+	case err != nil:
+		errMsg = "webhook post failed: " + err.Error()
+	case code < 200 || code > 299:
+		errMsg = fmt.Sprintf("webhook returned HTTP %d", code)
+	}
+
+	newAttempt := d.AttemptCount + 1
+	if errMsg == "" {
+		return w.updateWebhook(ctx, d.DeliveryID, "DELIVERED", newAttempt, "", 0)
+	}
+
+	max := d.MaxAttempts
+	if max <= 0 {
+		max = webhookMaxAttempts
+	}
+	if newAttempt >= max {
+		return w.updateWebhook(ctx, d.DeliveryID, "DLQ", newAttempt, errMsg, 0)
+	}
+
+	nextRetryAt := time.Now().UnixMilli() + computeWebhookBackoffMs(newAttempt)
+	if err := w.updateWebhook(ctx, d.DeliveryID, "FAILED", newAttempt, errMsg, nextRetryAt); err != nil {
+		return err
+	}
+	if err := w.hookRetries.PublishWebhookRetry(ctx, d.DeliveryID, nextRetryAt); err != nil {
+		// Without a retry message the redelivery would find it not yet due
+		// and drop it; clear the retry time so it can be claimed at once
+		if rerr := w.hooks.UpdateWebhookDelivery(ctx, d.DeliveryID, "FAILED", newAttempt, errMsg, 0, time.Now().UnixMilli()); rerr != nil {
+			log.Println("worker: clear webhook retry time failed:", d.DeliveryID, rerr)
+		}
+		return err
+	}
+	return nil
+}
+
+// updateWebhook records the outcome of a claimed delivery, handing the
+// claim back if the write fails.
+func (w *worker) updateWebhook(ctx context.Context, deliveryID, status string, attemptCount int, lastError string, nextRetryAt int64) error {
+	err := w.hooks.UpdateWebhookDelivery(ctx, deliveryID, status, attemptCount, lastError, nextRetryAt, time.Now().UnixMilli())
+	if err != nil {
+		return w.releaseWebhook(ctx, deliveryID, err)
+	}
+	return nil
+}
+
+// releaseWebhook hands a claimed delivery back after cause and returns
+// cause, so the Kafka message is not committed. A delivery that was posted
+// but whose DELIVERED write failed is posted again.
+func (w *worker) releaseWebhook(ctx context.Context, deliveryID string, cause error) error {
+	ok, err := w.hooks.ReleaseWebhookDelivery(ctx, deliveryID, "released: "+cause.Error(), time.Now().UnixMilli())
+	if err != nil {
+		log.Println("worker: release webhook claim failed:", deliveryID, err)
+	} else if ok {
+		log.Println("worker: released claim on", deliveryID, "after:", cause)
+	}
+	return cause
+}
+
+// computeWebhookBackoffMs doubles from 5s, capped at 10 minutes.
+// Receivers are often down for longer than an email provider blip.
+func computeWebhookBackoffMs(attempt int) int64 {
+	backoff := int64(5000) << (attempt - 1)
+	if backoff > 10*60*1000 {
+		backoff = 10 * 60 * 1000
+	}
+	return backoff
+}
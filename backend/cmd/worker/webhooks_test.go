@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"safe-notify/internal/models"
+	"safe-notify/internal/webhook"
+)
+
+// fakeHooks keeps webhook deliveries in memory with the store's claim rules.
+type fakeHooks struct {
+	deliveries map[string]*models.WebhookDelivery
+	subs       map[string]*models.WebhookSubscription
+	subErrs    int // GetWebhookSubscription fails this many times
+}
+
+func (f *fakeHooks) GetWebhookDelivery(_ context.Context, id string) (*models.WebhookDelivery, error) {
+	d, ok := f.deliveries[id]
+	if !ok {
+		return nil, nil
+	}
+	cp := *d
+	return &cp, nil
+}
+
+func (f *fakeHooks) ClaimWebhookDelivery(_ context.Context, id string, nowMs int64) (bool, error) {
+	d := f.deliveries[id]
+	if d.Status != "PENDING" && d.Status != "FAILED" {
+		return false, nil
+	}
+	d.Status, d.UpdatedAt = "PROCESSING", nowMs
+	return true, nil
+}
+
+func (f *fakeHooks) ReleaseWebhookDelivery(_ context.Context, id, reason string, nowMs int64) (bool, error) {
+	d := f.deliveries[id]
+	if d.Status != "PROCESSING" {
+		return false, nil
+	}
+	d.Status, d.NextRetryAt, d.LastError, d.UpdatedAt = "FAILED", 0, reason, nowMs
+	return true, nil
+}
+
+func (f *fakeHooks) UpdateWebhookDelivery(_ context.Context, id, status string, attempts int, lastError string, nextRetryAt, nowMs int64) error {
+	d := f.deliveries[id]
+	d.Status, d.AttemptCount, d.LastError, d.NextRetryAt, d.UpdatedAt = status, attempts, lastError, nextRetryAt, nowMs
+	return nil
+}
+
+func (f *fakeHooks) GetWebhookSubscription(_ context.Context, id string) (*models.WebhookSubscription, error) {
+	if f.subErrs > 0 {
+		f.subErrs--
+		return nil, errors.New("store unavailable")
+	}
+	return f.subs[id], nil
+}
+
+type fakeRetries struct{ err error }
+
+func (f fakeRetries) PublishWebhookRetry(context.Context, string, int64) error { return f.err }
+
+func newHookWorker(t *testing.T, status int) (*worker, *fakeHooks, *int32) {
+	t.Helper()
+	var posts int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&posts, 1)
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+
+	hooks := &fakeHooks{
+		deliveries: map[string]*models.WebhookDelivery{
+			"whd_1": {DeliveryID: "whd_1", URL: srv.URL, SubscriptionID: "sub_1", Payload: `{}`, Status: "PENDING", MaxAttempts: webhookMaxAttempts},
+		},
+		subs: map[string]*models.WebhookSubscription{
+			"sub_1": {SubscriptionID: "sub_1", Secret: "s3cret"},
+		},
+	}
+	w := &worker{
+		hooks:       hooks,
+		hookRetries: fakeRetries{},
+		httpClient:  webhook.NewClient(5*time.Second, true),
+	}
+	return w, hooks, &posts
+}
+
+func TestProcessWebhookReleasesClaimOnError(t *testing.T) {
+	ctx := context.Background()
+	w, hooks, posts := newHookWorker(t, http.StatusOK)
+	hooks.subErrs = 1
+
+	if err := w.processWebhook(ctx, "whd_1"); err == nil {
+		t.Fatal("processWebhook succeeded, want the subscription lookup error")
+	}
+	if d := hooks.deliveries["whd_1"]; d.Status != "FAILED" || d.NextRetryAt != 0 {
+		t.Fatalf("after error: status %s, next_retry_at %d; want FAILED and claimable", d.Status, d.NextRetryAt)
+	}
+
+	// The redelivered message claims it again
+	if err := w.processWebhook(ctx, "whd_1"); err != nil {
+		t.Fatalf("redelivery: %v", err)
+	}
+	if d := hooks.deliveries["whd_1"]; d.Status != "DELIVERED" || d.AttemptCount != 1 {
+		t.Errorf("after redelivery: status %s, attempts %d; want DELIVERED after 1", d.Status, d.AttemptCount)
+	}
+	if n := atomic.LoadInt32(posts); n != 1 {
+		t.Errorf("posted %d times, want 1", n)
+	}
+}
+
+func TestProcessWebhookClearsRetryTimeWhenPublishFails(t *testing.T) {
+	w, hooks, _ := newHookWorker(t, http.StatusServiceUnavailable)
+	w.hookRetries = fakeRetries{err: errors.New("kafka down")}
+
+	if err := w.processWebhook(context.Background(), "whd_1"); err == nil {
+		t.Fatal("processWebhook succeeded, want the publish error")
+	}
+	d := hooks.deliveries["whd_1"]
+	if d.Status != "FAILED" || d.AttemptCount != 1 || d.NextRetryAt != 0 {
+		t.Errorf("status %s, attempts %d, next_retry_at %d; want FAILED after 1 with no retry time", d.Status, d.AttemptCount, d.NextRetryAt)
+	}
+}
+
+func TestProcessWebhookWithoutSigningSecret(t *testing.T) {
+	w, hooks, posts := newHookWorker(t, http.StatusOK)
+	d := hooks.deliveries["whd_1"]
+	d.SubscriptionID = ""
+
+	if err := w.processWebhook(context.Background(), "whd_1"); err != nil {
+		t.Fatal(err)
+	}
+	if d.Status != "DLQ" || d.LastError != "signing secret not configured" {
+		t.Errorf("status %s (%q), want DLQ", d.Status, d.LastError)
+	}
+	if n := atomic.LoadInt32(posts); n != 0 {
+		t.Errorf("posted %d times unsigned", n)
+	}
+}
@@ -0,0 +1,163 @@
+package breaker
+
+import (
+	"sync"
+	"time"
+)
+
+type State string
+
+const (
+	Closed   State = "closed"    // calls flow; outcomes are counted
+	Open     State = "open"      // calls are refused until OpenFor has passed
+	HalfOpen State = "half_open" // one probe call decides: closed again or re-open
+)
+
+// Config sets when the breaker trips: at least MinRequests outcomes in the
+// last Window with a failure ratio of FailureRate or more.
+type Config struct {
+	FailureRate float64
+	MinRequests int
+	Window      time.Duration
+	OpenFor     time.Duration
+}
+
+// Breaker is an in-process circuit breaker. It is safe for concurrent use.
+type Breaker struct {
+	name string
+	cfg  Config
+	now  func() time.Time // time.Now; tests set their own clock
+
+	mu       sync.Mutex
+	state    State
+	openedAt time.Time
+	probing  bool
+	outcomes []outcome
+	trips    int64
+
+	OnStateChange func(name string, from, to State) // optional, called with the lock held
+}
+
+type outcome struct {
+	at     time.Time
+	failed bool
+}
+
+func New(name string, cfg Config) *Breaker {
+	return &Breaker{name: name, cfg: cfg, state: Closed, now: time.Now}
+}
+
+// Allow reports whether a call may go ahead. When it may not, retryIn is
+// how long until the breaker will let a probe through.
+func (b *Breaker) Allow() (ok bool, retryIn time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case Open:
+		left := b.cfg.OpenFor - b.now().Sub(b.openedAt)
+		if left > 0 {
+			return false, left
+		}
+		b.setState(HalfOpen)
+		fallthrough
+	case HalfOpen:
+		if b.probing {
+			return false, b.cfg.OpenFor
+		}
+		b.probing = true
+		return true, 0
+	default:
+		return true, 0
+	}
+}
+
+// Record reports the outcome of a call that Allow let through.
+func (b *Breaker) Record(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	if b.state == HalfOpen {
+		b.probing = false
+		if failed {
+			b.trip(now)
+		} else {
+			b.outcomes = nil
+			b.setState(Closed)
+		}
+		return
+	}
+	if b.state == Open {
+		return // a call that started before the breaker opened
+	}
+
+	b.outcomes = append(b.outcomes, outcome{at: now, failed: failed})
+	cutoff := now.Add(-b.cfg.Window)
+	i := 0
+	for i < len(b.outcomes) && b.outcomes[i].at.Before(cutoff) {
+		i++
+	}
+	b.outcomes = b.outcomes[i:]
+
+	if len(b.outcomes) < b.cfg.MinRequests {
+		return
+	}
+	failures := 0
+	for _, o := range b.outcomes {
+		if o.failed {
+			failures++
+		}
+	}
+	if float64(failures)/float64(len(b.outcomes)) >= b.cfg.FailureRate {
+		b.trip(now)
+	}
+}
+
+func (b *Breaker) trip(now time.Time) {
+	b.openedAt = now
+	b.outcomes = nil
+	b.trips++
+	b.setState(Open)
+}
+
+func (b *Breaker) setState(to State) {
+	if b.state == to {
+		return
+	}
+	from := b.state
+	b.state = to
+	if b.OnStateChange != nil {
+		b.OnStateChange(b.name, from, to)
+	}
+}
+
+// Snapshot is the breaker's state for status endpoints and metrics.
+type Snapshot struct {
+	Name        string  `json:"name"`
+	State       State   `json:"state"`
+	Requests    int     `json:"requests"` // in the current window
+	FailureRate float64 `json:"failure_rate"`
+	Trips       int64   `json:"trips"`
+	OpenedAt    int64   `json:"opened_at,omitempty"` // epoch ms
+}
+
+func (b *Breaker) Snapshot() Snapshot {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s := Snapshot{Name: b.name, State: b.state, Requests: len(b.outcomes), Trips: b.trips}
+	failures := 0
+	for _, o := range b.outcomes {
+		if o.failed {
+			failures++
+		}
+	}
+	if len(b.outcomes) > 0 {
+		s.FailureRate = float64(failures) / float64(len(b.outcomes))
+	}
+	if b.state != Closed {
+		s.OpenedAt = b.openedAt.UnixMilli()
+	}
+	return s
+}
@@ -0,0 +1,205 @@
+package breaker
+
+import (
+	"testing"
+	"time"
+)
+
+// clock is a manual time source for a Breaker.
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time          { return c.t }
+func (c *clock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+var testConfig = Config{
+	FailureRate: 0.5,
+	MinRequests: 4,
+	Window:      time.Minute,
+	OpenFor:     30 * time.Second,
+}
+
+func newTestBreaker() (*Breaker, *clock) {
+	c := &clock{t: time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)}
+	b := New("EMAIL", testConfig)
+	b.now = c.now
+	return b, c
+}
+
+// step is one thing that happens to a breaker. An outcome is recorded when
+// record is set; Allow is called first when allow is set too.
+type step struct {
+	advance time.Duration
+	allow   bool
+	record  bool
+	failed  bool
+
+	wantAllowed bool
+	wantState   State
+}
+
+func record(failed bool, want State) step {
+	return step{record: true, failed: failed, wantState: want}
+}
+
+func TestBreaker(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "stays closed below MinRequests",
+			steps: []step{
+				record(true, Closed),
+				record(true, Closed),
+				record(true, Closed),
+			},
+		},
+		{
+			name: "trips at FailureRate once MinRequests is reached",
+			steps: []step{
+				record(false, Closed),
+				record(true, Closed),
+				record(false, Closed),
+				record(true, Open),
+			},
+		},
+		{
+			name: "stays closed under FailureRate",
+			steps: []step{
+				record(false, Closed),
+				record(false, Closed),
+				record(true, Closed),
+				record(false, Closed),
+				record(false, Closed),
+			},
+		},
+		{
+			name: "outcomes drop out of the window",
+			steps: []step{
+				record(true, Closed),
+				record(true, Closed),
+				record(true, Closed),
+				{advance: 61 * time.Second, record: true, failed: true, wantState: Closed},
+				record(false, Closed),
+				record(false, Closed),
+				record(false, Closed), // 1 of 4; with the old ones it would be 4 of 7
+			},
+		},
+		{
+			name: "open refuses until OpenFor has passed",
+			steps: []step{
+				record(true, Closed),
+				record(true, Closed),
+				record(true, Closed),
+				record(true, Open),
+				{advance: 29 * time.Second, allow: true, wantAllowed: false, wantState: Open},
+				{advance: time.Second, allow: true, wantAllowed: true, wantState: HalfOpen},
+			},
+		},
+		{
+			name: "only one half-open probe",
+			steps: []step{
+				record(true, Closed),
+				record(true, Closed),
+				record(true, Closed),
+				record(true, Open),
+				{advance: 30 * time.Second, allow: true, wantAllowed: true, wantState: HalfOpen},
+				{allow: true, wantAllowed: false, wantState: HalfOpen},
+				{advance: time.Hour, allow: true, wantAllowed: false, wantState: HalfOpen},
+			},
+		},
+		{
+			name: "failed probe re-opens",
+			steps: []step{
+				record(true, Closed),
+				record(true, Closed),
+				record(true, Closed),
+				record(true, Open),
+				{advance: 30 * time.Second, allow: true, wantAllowed: true, record: true, failed: true, wantState: Open},
+				{advance: 29 * time.Second, allow: true, wantAllowed: false, wantState: Open},
+				{advance: time.Second, allow: true, wantAllowed: true, wantState: HalfOpen},
+			},
+		},
+		{
+			name: "successful probe closes with a fresh window",
+			steps: []step{
+				record(true, Closed),
+				record(true, Closed),
+				record(true, Closed),
+				record(true, Open),
+				{advance: 30 * time.Second, allow: true, wantAllowed: true, record: true, failed: false, wantState: Closed},
+				{allow: true, wantAllowed: true, wantState: Closed},
+				record(true, Closed),
+				record(true, Closed),
+				record(true, Closed),
+			},
+		},
+		{
+			name: "late outcome while open is ignored",
+			steps: []step{
+				record(true, Closed),
+				record(true, Closed),
+				record(true, Closed),
+				record(true, Open),
+				record(false, Open),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, c := newTestBreaker()
+			for i, s := range tt.steps {
+				c.advance(s.advance)
+				if s.allow {
+					if ok, _ := b.Allow(); ok != s.wantAllowed {
+						t.Fatalf("step %d: Allow = %v, want %v", i, ok, s.wantAllowed)
+					}
+				}
+				if s.record {
+					b.Record(s.failed)
+				}
+				if got := b.Snapshot().State; got != s.wantState {
+					t.Fatalf("step %d: state = %s, want %s", i, got, s.wantState)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryIn(t *testing.T) {
+	b, c := newTestBreaker()
+	for i := 0; i < testConfig.MinRequests; i++ {
+		b.Record(true)
+	}
+	c.advance(10 * time.Second)
+	if ok, retryIn := b.Allow(); ok || retryIn != 20*time.Second {
+		t.Errorf("Allow = %v, %v; want refused for 20s", ok, retryIn)
+	}
+}
+
+func TestStateChanges(t *testing.T) {
+	b, c := newTestBreaker()
+	var changes []State
+	b.OnStateChange = func(_ string, _, to State) { changes = append(changes, to) }
+
+	for i := 0; i < testConfig.MinRequests; i++ {
+		b.Record(true)
+	}
+	c.advance(testConfig.OpenFor)
+	b.Allow()
+	b.Record(false)
+
+	want := []State{Open, HalfOpen, Closed}
+	if len(changes) != len(want) {
+		t.Fatalf("changes = %v, want %v", changes, want)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Fatalf("changes = %v, want %v", changes, want)
+		}
+	}
+	if s := b.Snapshot(); s.Trips != 1 || s.Requests != 0 {
+		t.Errorf("Snapshot = %+v, want 1 trip and an empty window", s)
+	}
+}
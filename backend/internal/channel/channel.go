@@ -0,0 +1,81 @@
+package channel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"safe-notify/internal/models"
+)
+
+// Channel delivers one task over one transport (EMAIL, WEBHOOK, ...).
+// A nil error means delivered. Wrap errors with Permanent or RetryAfter
+// to change how the worker schedules the next attempt.
+type Channel interface {
+	Deliver(ctx context.Context, task models.Task) error
+}
+
+// Dispatcher routes a task to the Channel registered for task.Channel.
+type Dispatcher struct {
+	channels map[string]Channel
+}
+
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{channels: map[string]Channel{}}
+}
+
+func (d *Dispatcher) Register(name string, c Channel) {
+	d.channels[name] = c
+}
+
+func (d *Dispatcher) Deliver(ctx context.Context, task models.Task) error {
+	c, ok := d.channels[task.Channel]
+	if !ok {
+		return Permanent(fmt.Errorf("channel %q is not configured on this worker", task.Channel))
+	}
+	return c.Deliver(ctx, task)
+}
+
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as not worth retrying; the task goes straight to DLQ.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return permanentError{err: err}
+}
+
+func IsPermanent(err error) bool {
+	var pe permanentError
+	return errors.As(err, &pe)
+}
+
+type retryAfterError struct {
+	err   error
+	delay time.Duration
+}
+
+func (e retryAfterError) Error() string { return e.err.Error() }
+func (e retryAfterError) Unwrap() error { return e.err }
+
+// RetryAfter marks err as retryable no sooner than delay (e.g. HTTP Retry-After).
+func RetryAfter(err error, delay time.Duration) error {
+	if err == nil {
+		return nil
+	}
+	return retryAfterError{err: err, delay: delay}
+}
+
+// RetryDelay returns the provider-requested delay, if any.
+func RetryDelay(err error) (time.Duration, bool) {
+	var re retryAfterError
+	if errors.As(err, &re) {
+		return re.delay, true
+	}
+	return 0, false
+}
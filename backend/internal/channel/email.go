@@ -0,0 +1,120 @@
+package channel
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"safe-notify/internal/email"
+	"safe-notify/internal/models"
+	"safe-notify/internal/tenant"
+	"safe-notify/internal/unsubscribe"
+)
+
+type Email struct {
+	Sender  email.Sender
+	Unsub   *unsubscribe.Signer // nil = no unsubscribe links
+	Tenants tenant.Registry     // per-tenant From address and templates
+}
+
+func (c *Email) Deliver(ctx context.Context, task models.Task) error {
+	subject := fmt.Sprintf("[Safe-Notify] %s (%s)", task.EventType, task.EntityID)
+	body := fmt.Sprintf(
+		"TaskID: %s\nEventType: %s\nEntityID: %s\nPriority: %s\nChannel: %s\n",
+		task.TaskID, task.EventType, task.EntityID, task.Priority, task.Channel,
+	)
+
+	cfg := c.Tenants.Get(task.TenantID)
+	if len(task.Digest) > 0 {
+		subject, body = renderDigestEmail(task)
+	} else if cfg.TemplatesDir != "" {
+		var err error
+		if subject, err = renderEmailTemplate(cfg.TemplatesDir, task, "subject", subject); err != nil {
+			return Permanent(err)
+		}
+		if body, err = renderEmailTemplate(cfg.TemplatesDir, task, "body", body); err != nil {
+			return Permanent(err)
+		}
+	}
+
+	msg := email.Message{From: cfg.FromEmail, To: task.To(), Subject: subject, Body: body}
+	if c.Unsub != nil {
+		// RFC 8058 one-click unsubscribe + a footer link to the same endpoint
+		link := c.Unsub.URL(task.TenantID, strings.ToLower(task.To()), task.EventType)
+		msg.Body += "\n--\nStop receiving " + task.EventType + " notifications: " + link + "\n"
+		msg.Headers = map[string]string{
+			"List-Unsubscribe":      "<" + link + ">",
+			"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+		}
+	}
+
+	if err := c.Sender.Send(ctx, msg); err != nil {
+		return fmt.Errorf("SES send failed: %w", err)
+	}
+	return nil
+}
+
+func renderDigestEmail(task models.Task) (string, string) {
+	subject := fmt.Sprintf("[Safe-Notify] %d %s notifications", len(task.Digest), task.EventType)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d %s notifications since the last digest:\n\n", len(task.Digest), task.EventType)
+	for _, e := range task.Digest {
+		fmt.Fprintf(&b, "- %s (priority %s, task %s)\n", e.EntityID, e.Priority, e.TaskID)
+	}
+	fmt.Fprintf(&b, "\nDigestTaskID: %s\n", task.TaskID)
+	return subject, b.String()
+}
+
+// readTemplate reads <dir>/<event_type>.<locale>.<suffix> for the task's
+// locale ("pt-BR"), then its language ("pt"), then <event_type>.<suffix>.
+// The error is os.ErrNotExist if there is none of them.
+func readTemplate(dir string, task models.Task, suffix string) ([]byte, error) {
+	name := filepath.Base(task.EventType)
+	var files []string
+	if task.Locale != "" {
+		locale := filepath.Base(task.Locale)
+		files = append(files, name+"."+locale+"."+suffix)
+		if lang, _, ok := strings.Cut(locale, "-"); ok {
+			files = append(files, name+"."+lang+"."+suffix)
+		}
+	}
+	files = append(files, name+"."+suffix)
+
+	for _, f := range files {
+		b, err := os.ReadFile(filepath.Join(dir, f))
+		if !os.IsNotExist(err) {
+			return b, err
+		}
+	}
+	return nil, os.ErrNotExist
+}
+
+// renderEmailTemplate executes the task's <part>.tmpl template (see
+// readTemplate), or returns def if the tenant has no such template.
+func renderEmailTemplate(dir string, task models.Task, part, def string) (string, error) {
+	b, err := readTemplate(dir, task, part+".tmpl")
+	if os.IsNotExist(err) {
+		return def, nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	tmpl, err := template.New(task.EventType).Parse(string(b))
+	if err != nil {
+		return "", fmt.Errorf("email %s template %s: %w", part, task.EventType, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, task); err != nil {
+		return "", fmt.Errorf("email %s template %s: %w", part, task.EventType, err)
+	}
+	if part == "subject" {
+		return strings.TrimSpace(buf.String()), nil
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,45 @@
+package channel
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"safe-notify/internal/models"
+)
+
+func TestReadTemplatePicksLocale(t *testing.T) {
+	dir := t.TempDir()
+	for name, body := range map[string]string{
+		"ticket_escalated.body.tmpl":       "default",
+		"ticket_escalated.pt.body.tmpl":    "pt",
+		"ticket_escalated.fr-CA.body.tmpl": "fr-CA",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		locale string
+		want   string
+	}{
+		{"", "default"},
+		{"fr-CA", "fr-CA"},
+		{"pt-BR", "pt"},
+		{"pt", "pt"},
+		{"de-DE", "default"},
+		{"../../pt", "pt"}, // only ever a file name inside dir
+	}
+	for _, tt := range tests {
+		task := models.Task{EventType: "ticket_escalated", Locale: tt.locale}
+		b, err := readTemplate(dir, task, "body.tmpl")
+		if err != nil || string(b) != tt.want {
+			t.Errorf("locale %q: got %q, %v; want %q", tt.locale, b, err, tt.want)
+		}
+	}
+
+	if _, err := readTemplate(dir, models.Task{EventType: "other", Locale: "pt"}, "body.tmpl"); !os.IsNotExist(err) {
+		t.Errorf("missing template: err = %v, want not-exist", err)
+	}
+}
@@ -0,0 +1,37 @@
+package channel
+
+import (
+	"context"
+	"fmt"
+
+	"safe-notify/internal/models"
+)
+
+type InboxWriter interface {
+	PutInboxItem(ctx context.Context, item models.InboxItem) (bool, error)
+}
+
+// InApp stores the rendered notification in the recipient user's inbox.
+// The item ID comes from the task, so a retry after a crash is a no-op.
+type InApp struct {
+	Inbox InboxWriter
+}
+
+func (c *InApp) Deliver(ctx context.Context, task models.Task) error {
+	item := models.InboxItem{
+		UserID:    task.To(),
+		TenantID:  task.TenantID,
+		ItemID:    models.InboxItemID(task),
+		TaskID:    task.TaskID,
+		EventType: task.EventType,
+		EntityID:  task.EntityID,
+		Priority:  task.Priority,
+		Title:     fmt.Sprintf("%s: %s", task.EventType, task.EntityID),
+		Body:      fmt.Sprintf("%s priority %s for %s", task.Priority, task.EventType, task.EntityID),
+		CreatedAt: task.CreatedAt,
+	}
+
+	// false just means an earlier attempt already wrote it
+	_, err := c.Inbox.PutInboxItem(ctx, item)
+	return err
+}
@@ -0,0 +1,168 @@
+package channel
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"safe-notify/internal/models"
+)
+
+// DeviceRegistry is the part of the store the push channel needs.
+type DeviceRegistry interface {
+	ListDevices(ctx context.Context, tenantID, userID string) ([]models.Device, error)
+	DeleteDevice(ctx context.Context, tenantID, userID, token string) error
+}
+
+// errUnregistered means the provider no longer knows the token.
+var errUnregistered = errors.New("device token unregistered")
+
+// Push fans a task out to every device of the recipient user through an
+// FCM HTTP v1 compatible endpoint, pruning tokens the provider rejects.
+type Push struct {
+	Devices   DeviceRegistry
+	BaseURL   string // https://fcm.googleapis.com; point at a local stub for tests
+	ProjectID string
+	AuthToken string // OAuth bearer token for the messages:send call
+	Client    *http.Client
+}
+
+func NewPush(devices DeviceRegistry, baseURL, projectID, authToken string) *Push {
+	if baseURL == "" {
+		baseURL = "https://fcm.googleapis.com"
+	}
+	return &Push{
+		Devices:   devices,
+		BaseURL:   strings.TrimRight(baseURL, "/"),
+		ProjectID: projectID,
+		AuthToken: authToken,
+		Client:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Deliver succeeds if at least one device accepted the message. Otherwise it
+// returns the last retryable error, or a permanent one if nothing can be retried.
+func (c *Push) Deliver(ctx context.Context, task models.Task) error {
+	userID := task.To()
+	devices, err := c.Devices.ListDevices(ctx, task.TenantID, userID)
+	if err != nil {
+		return err
+	}
+	if len(devices) == 0 {
+		return Permanent(fmt.Errorf("user %s has no registered devices", userID))
+	}
+
+	delivered := 0
+	var retryErr error
+	for _, d := range devices {
+		err := c.send(ctx, d.Token, task)
+		switch {
+		case err == nil:
+			delivered++
+		case errors.Is(err, errUnregistered):
+			log.Println("push: pruning unregistered token for", userID)
+			if derr := c.Devices.DeleteDevice(ctx, task.TenantID, userID, d.Token); derr != nil {
+				log.Println("push: prune failed:", derr)
+			}
+		case IsPermanent(err):
+			log.Println("push: device rejected message:", err)
+		default:
+			retryErr = err
+		}
+	}
+
+	if delivered > 0 {
+		return nil
+	}
+	if retryErr != nil {
+		return retryErr
+	}
+	return Permanent(fmt.Errorf("no deliverable devices for user %s", userID))
+}
+
+type fcmMessage struct {
+	Message struct {
+		Token        string            `json:"token"`
+		Notification map[string]string `json:"notification"`
+		Data         map[string]string `json:"data"`
+	} `json:"message"`
+}
+
+func (c *Push) send(ctx context.Context, token string, task models.Task) error {
+	var m fcmMessage
+	m.Message.Token = token
+	m.Message.Notification = map[string]string{
+		"title": task.EventType,
+		"body":  task.EntityID,
+	}
+	m.Message.Data = map[string]string{
+		"task_id":    task.TaskID,
+		"event_type": task.EventType,
+		"entity_id":  task.EntityID,
+		"priority":   task.Priority,
+	}
+	body, err := json.Marshal(m)
+	if err != nil {
+		return Permanent(err)
+	}
+
+	url := fmt.Sprintf("%s/v1/projects/%s/messages:send", c.BaseURL, c.ProjectID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return Permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.AuthToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.AuthToken)
+	}
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("push post failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		return nil
+	}
+
+	// FCM: {"error": {"status": "NOT_FOUND", "details": [{"errorCode": "UNREGISTERED"}]}}
+	// APNs-style gateways: {"reason": "Unregistered" | "BadDeviceToken"}
+	var out struct {
+		Error struct {
+			Status  string `json:"status"`
+			Message string `json:"message"`
+			Details []struct {
+				ErrorCode string `json:"errorCode"`
+			} `json:"details"`
+		} `json:"error"`
+		Reason string `json:"reason"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&out)
+
+	codes := []string{out.Error.Status, out.Reason}
+	for _, d := range out.Error.Details {
+		codes = append(codes, d.ErrorCode)
+	}
+	for _, code := range codes {
+		switch code {
+		case "UNREGISTERED", "Unregistered", "BadDeviceToken":
+			return errUnregistered
+		}
+	}
+	if resp.StatusCode == http.StatusGone {
+		return errUnregistered
+	}
+
+	err = classifyHTTP("push provider", resp.StatusCode, resp.Header)
+	if out.Error.Message != "" {
+		err = fmt.Errorf("%w: %s", err, out.Error.Message)
+	}
+	return err
+}
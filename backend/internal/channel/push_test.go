@@ -0,0 +1,237 @@
+package channel
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+
+	"safe-notify/internal/models"
+)
+
+// fakeDevices is an in-memory DeviceRegistry that records pruned tokens.
+type fakeDevices struct {
+	mu      sync.Mutex
+	devices []models.Device
+	deleted []string
+}
+
+func (f *fakeDevices) ListDevices(_ context.Context, tenantID, userID string) ([]models.Device, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []models.Device
+	for _, d := range f.devices {
+		if d.TenantID == tenantID && d.UserID == userID {
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeDevices) DeleteDevice(_ context.Context, tenantID, userID, token string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.deleted = append(f.deleted, token)
+	kept := f.devices[:0]
+	for _, d := range f.devices {
+		if d.TenantID != tenantID || d.UserID != userID || d.Token != token {
+			kept = append(kept, d)
+		}
+	}
+	f.devices = kept
+	return nil
+}
+
+func (f *fakeDevices) pruned() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := append([]string(nil), f.deleted...)
+	sort.Strings(out)
+	return out
+}
+
+func newFakeDevices(tokens ...string) *fakeDevices {
+	f := &fakeDevices{}
+	for _, tok := range tokens {
+		f.devices = append(f.devices, models.Device{TenantID: "t1", UserID: "user_1", Token: tok, Platform: "ANDROID"})
+	}
+	return f
+}
+
+// fcmStub stands in for the FCM messages:send endpoint and answers each
+// request according to the device token in its body.
+type fcmStub struct {
+	*httptest.Server
+	mu     sync.Mutex
+	tokens []string
+	paths  []string
+	auth   []string
+	data   []map[string]string
+}
+
+func newFCMStub(t *testing.T, respond func(w http.ResponseWriter, token string)) *fcmStub {
+	t.Helper()
+	s := &fcmStub{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var m fcmMessage
+		_ = json.Unmarshal(body, &m)
+
+		s.mu.Lock()
+		s.tokens = append(s.tokens, m.Message.Token)
+		s.paths = append(s.paths, r.URL.Path)
+		s.auth = append(s.auth, r.Header.Get("Authorization"))
+		s.data = append(s.data, m.Message.Data)
+		s.mu.Unlock()
+
+		respond(w, m.Message.Token)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *fcmStub) sentTo() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := append([]string(nil), s.tokens...)
+	sort.Strings(out)
+	return out
+}
+
+func pushTask() models.Task {
+	return models.Task{
+		TaskID:    "task_1",
+		TenantID:  "t1",
+		EventType: "ticket_escalated",
+		EntityID:  "TICKET-1",
+		Channel:   "PUSH",
+		Recipient: "user_1",
+		Priority:  "HIGH",
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestPushFansOutToEveryDevice(t *testing.T) {
+	stub := newFCMStub(t, func(w http.ResponseWriter, _ string) {
+		_, _ = io.WriteString(w, `{"name": "projects/p1/messages/1"}`)
+	})
+	devices := newFakeDevices("tok-a", "tok-b", "tok-c")
+	c := NewPush(devices, stub.URL, "p1", "ya29.test")
+
+	if err := c.Deliver(context.Background(), pushTask()); err != nil {
+		t.Fatalf("Deliver: %v", err)
+	}
+	if got := stub.sentTo(); !equalStrings(got, []string{"tok-a", "tok-b", "tok-c"}) {
+		t.Errorf("sent to %v, want every device", got)
+	}
+	for i := range stub.paths {
+		if stub.paths[i] != "/v1/projects/p1/messages:send" {
+			t.Errorf("path = %q", stub.paths[i])
+		}
+		if stub.auth[i] != "Bearer ya29.test" {
+			t.Errorf("Authorization = %q", stub.auth[i])
+		}
+		if stub.data[i]["task_id"] != "task_1" || stub.data[i]["priority"] != "HIGH" {
+			t.Errorf("data = %v", stub.data[i])
+		}
+	}
+	if p := devices.pruned(); len(p) != 0 {
+		t.Errorf("pruned %v, want none", p)
+	}
+}
+
+func TestPushPrunesUnregisteredTokens(t *testing.T) {
+	stub := newFCMStub(t, func(w http.ResponseWriter, token string) {
+		switch token {
+		case "tok-fcm":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"error": {"status": "NOT_FOUND", "details": [{"errorCode": "UNREGISTERED"}]}}`)
+		case "tok-gone":
+			w.WriteHeader(http.StatusGone)
+		case "tok-apns":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = io.WriteString(w, `{"reason": "BadDeviceToken"}`)
+		default:
+			_, _ = io.WriteString(w, `{}`)
+		}
+	})
+	devices := newFakeDevices("tok-ok", "tok-fcm", "tok-gone", "tok-apns")
+	c := NewPush(devices, stub.URL, "p1", "")
+
+	// One live device is enough for the task to count as delivered
+	if err := c.Deliver(context.Background(), pushTask()); err != nil {
+		t.Fatalf("Deliver: %v", err)
+	}
+	if got := devices.pruned(); !equalStrings(got, []string{"tok-apns", "tok-fcm", "tok-gone"}) {
+		t.Errorf("pruned %v", got)
+	}
+	left, _ := devices.ListDevices(context.Background(), "t1", "user_1")
+	if len(left) != 1 || left[0].Token != "tok-ok" {
+		t.Errorf("remaining devices = %v, want only tok-ok", left)
+	}
+}
+
+func TestPushAllUnregisteredIsPermanent(t *testing.T) {
+	stub := newFCMStub(t, func(w http.ResponseWriter, _ string) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = io.WriteString(w, `{"reason": "Unregistered"}`)
+	})
+	devices := newFakeDevices("tok-a", "tok-b")
+	c := NewPush(devices, stub.URL, "p1", "")
+
+	err := c.Deliver(context.Background(), pushTask())
+	if err == nil || !IsPermanent(err) {
+		t.Fatalf("Deliver = %v, want a permanent error", err)
+	}
+	if got := devices.pruned(); !equalStrings(got, []string{"tok-a", "tok-b"}) {
+		t.Errorf("pruned %v, want both tokens", got)
+	}
+}
+
+func TestPushProviderOutageIsRetried(t *testing.T) {
+	stub := newFCMStub(t, func(w http.ResponseWriter, _ string) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = io.WriteString(w, `{"error": {"status": "UNAVAILABLE", "message": "try again"}}`)
+	})
+	devices := newFakeDevices("tok-a", "tok-b")
+	c := NewPush(devices, stub.URL, "p1", "")
+
+	err := c.Deliver(context.Background(), pushTask())
+	if err == nil || IsPermanent(err) {
+		t.Fatalf("Deliver = %v, want a retryable error", err)
+	}
+	if got := stub.sentTo(); len(got) != 2 {
+		t.Errorf("sent to %v, want both devices attempted", got)
+	}
+	if p := devices.pruned(); len(p) != 0 {
+		t.Errorf("pruned %v on an outage", p)
+	}
+}
+
+func TestPushWithoutDevicesIsPermanent(t *testing.T) {
+	stub := newFCMStub(t, func(w http.ResponseWriter, _ string) {})
+	c := NewPush(newFakeDevices(), stub.URL, "p1", "")
+
+	err := c.Deliver(context.Background(), pushTask())
+	if err == nil || !IsPermanent(err) {
+		t.Fatalf("Deliver = %v, want a permanent error", err)
+	}
+	if got := stub.sentTo(); len(got) != 0 {
+		t.Errorf("posted %v without devices", got)
+	}
+}
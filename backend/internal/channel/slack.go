@@ -0,0 +1,204 @@
+package channel
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"safe-notify/internal/models"
+	"safe-notify/internal/webhook"
+)
+
+// defaultSlackBlocks is used when no <event_type>.json.tmpl exists.
+// Templates render a Block Kit "blocks" array; use {{json .Field}} for strings.
+const defaultSlackBlocks = `[
+  {"type": "header", "text": {"type": "plain_text", "text": {{json .EventType}}}},
+  {"type": "section", "fields": [
+    {"type": "mrkdwn", "text": {{json (printf "*Entity*\n%s" .EntityID)}}},
+    {"type": "mrkdwn", "text": {{json (printf "*Priority*\n%s" .Priority)}}}
+  ]},
+  {"type": "context", "elements": [{"type": "mrkdwn", "text": {{json (printf "task %s" .TaskID)}}}]}
+]`
+
+// Slack posts Block Kit messages through an incoming webhook or chat.postMessage.
+//
+// Routing: task.Recipient if set, else Channels[event_type], else DefaultChannel.
+// Targets starting with http(s):// are treated as incoming webhooks, anything
+// else as a chat.postMessage channel. A per-task webhook URL must be one of
+// the configured ones, so callers can't make the worker POST anywhere.
+type Slack struct {
+	APIURL         string // https://slack.com/api; point at a local stand-in for tests
+	BotToken       string // required for chat.postMessage targets
+	DefaultChannel string
+	Channels       map[string]string
+	TemplatesDir   string // optional directory of <event_type>.json.tmpl
+	Client         *http.Client
+}
+
+func NewSlack(apiURL, botToken, defaultChannel string, channels map[string]string, templatesDir string) *Slack {
+	if apiURL == "" {
+		apiURL = "https://slack.com/api"
+	}
+	return &Slack{
+		APIURL:         strings.TrimRight(apiURL, "/"),
+		BotToken:       botToken,
+		DefaultChannel: defaultChannel,
+		Channels:       channels,
+		TemplatesDir:   templatesDir,
+		Client:         &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (c *Slack) Deliver(ctx context.Context, task models.Task) error {
+	target := task.Recipient // explicit per-task channel wins
+	if target == "" {
+		target = c.Channels[task.EventType]
+	}
+	if target == "" {
+		target = c.DefaultChannel
+	}
+	if target == "" {
+		return Permanent(fmt.Errorf("no Slack channel configured for %s", task.EventType))
+	}
+
+	blocks, err := c.render(task)
+	if err != nil {
+		return Permanent(err)
+	}
+	msg := map[string]any{
+		"text":   fmt.Sprintf("%s (%s)", task.EventType, task.EntityID), // notification fallback
+		"blocks": blocks,
+	}
+
+	if isURL(target) {
+		if !c.configuredWebhook(target) {
+			// Don't echo the URL: incoming-webhook URLs are credentials
+			return Permanent(fmt.Errorf("slack recipient is not a configured incoming webhook"))
+		}
+		return c.postIncomingWebhook(ctx, target, msg)
+	}
+	msg["channel"] = target
+	return c.postMessage(ctx, msg)
+}
+
+func isURL(s string) bool {
+	return strings.HasPrefix(s, "https://") || strings.HasPrefix(s, "http://")
+}
+
+func (c *Slack) configuredWebhook(url string) bool {
+	if url == c.DefaultChannel {
+		return true
+	}
+	for _, v := range c.Channels {
+		if v == url {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Slack) render(task models.Task) (json.RawMessage, error) {
+	src := defaultSlackBlocks
+	if c.TemplatesDir != "" {
+		b, err := readTemplate(c.TemplatesDir, task, "json.tmpl")
+		if err == nil {
+			src = string(b)
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+
+	tmpl, err := template.New(task.EventType).Funcs(template.FuncMap{
+		"json": func(v any) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+	}).Parse(src)
+	if err != nil {
+		return nil, fmt.Errorf("slack template %s: %w", task.EventType, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, task); err != nil {
+		return nil, fmt.Errorf("slack template %s: %w", task.EventType, err)
+	}
+	if !json.Valid(buf.Bytes()) {
+		return nil, fmt.Errorf("slack template %s did not render valid JSON", task.EventType)
+	}
+	return json.RawMessage(buf.Bytes()), nil
+}
+
+func (c *Slack) postIncomingWebhook(ctx context.Context, url string, msg map[string]any) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return Permanent(err)
+	}
+
+	code, h, err := webhook.Post(ctx, c.Client, webhook.Request{URL: url, Body: body})
+	if err != nil {
+		return fmt.Errorf("slack post failed: %w", err)
+	}
+	return classifyHTTP("slack", code, h)
+}
+
+// slackRetryable are chat.postMessage "ok": false errors worth another attempt.
+var slackRetryable = map[string]bool{
+	"ratelimited":         true,
+	"internal_error":      true,
+	"fatal_error":         true,
+	"service_unavailable": true,
+	"request_timeout":     true,
+}
+
+func (c *Slack) postMessage(ctx context.Context, msg map[string]any) error {
+	if c.BotToken == "" {
+		return Permanent(fmt.Errorf("SLACK_BOT_TOKEN is required for channel %v", msg["channel"]))
+	}
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return Permanent(err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.APIURL+"/chat.postMessage", bytes.NewReader(body))
+	if err != nil {
+		return Permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Authorization", "Bearer "+c.BotToken)
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("slack post failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if err := classifyHTTP("slack", resp.StatusCode, resp.Header); err != nil {
+		return err
+	}
+
+	// chat.postMessage reports most failures as 200 + {"ok": false}
+	var out struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return fmt.Errorf("slack response: %w", err)
+	}
+	if out.OK {
+		return nil
+	}
+	err = fmt.Errorf("slack error: %s", out.Error)
+	if slackRetryable[out.Error] {
+		if d, ok := webhook.ParseRetryAfter(resp.Header); ok {
+			return RetryAfter(err, d)
+		}
+		return err
+	}
+	return Permanent(err)
+}
@@ -0,0 +1,239 @@
+package channel
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"safe-notify/internal/models"
+)
+
+// slackStub stands in for slack.com/api and incoming webhooks. Each request
+// is recorded and answered by respond.
+type slackStub struct {
+	*httptest.Server
+	hits    atomic.Int32
+	last    map[string]any
+	auth    string
+	respond func(w http.ResponseWriter)
+}
+
+func newSlackStub(t *testing.T, respond func(w http.ResponseWriter)) *slackStub {
+	t.Helper()
+	s := &slackStub{respond: respond}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.hits.Add(1)
+		s.auth = r.Header.Get("Authorization")
+		body, _ := io.ReadAll(r.Body)
+		s.last = nil
+		_ = json.Unmarshal(body, &s.last)
+		s.respond(w)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func slackOK(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = io.WriteString(w, `{"ok": true}`)
+}
+
+func slackTask() models.Task {
+	return models.Task{
+		TaskID:    "task_1",
+		EventType: "ticket_escalated",
+		EntityID:  "TICKET-1",
+		Channel:   "SLACK",
+		Priority:  "HIGH",
+	}
+}
+
+func TestSlackRendersDefaultBlocks(t *testing.T) {
+	stub := newSlackStub(t, slackOK)
+	c := NewSlack(stub.URL, "xoxb-test", "#oncall", nil, "")
+
+	if err := c.Deliver(context.Background(), slackTask()); err != nil {
+		t.Fatalf("Deliver: %v", err)
+	}
+	if stub.auth != "Bearer xoxb-test" {
+		t.Errorf("Authorization = %q", stub.auth)
+	}
+	if stub.last["channel"] != "#oncall" {
+		t.Errorf("channel = %v, want #oncall", stub.last["channel"])
+	}
+	if stub.last["text"] != "ticket_escalated (TICKET-1)" {
+		t.Errorf("fallback text = %v", stub.last["text"])
+	}
+
+	blocks, _ := stub.last["blocks"].([]any)
+	if len(blocks) != 3 {
+		t.Fatalf("got %d blocks, want 3: %v", len(blocks), stub.last["blocks"])
+	}
+	header, _ := blocks[0].(map[string]any)
+	text, _ := header["text"].(map[string]any)
+	if header["type"] != "header" || text["text"] != "ticket_escalated" {
+		t.Errorf("header block = %v", header)
+	}
+}
+
+func TestSlackRendersTemplate(t *testing.T) {
+	dir := t.TempDir()
+	tmpl := `[{"type": "section", "text": {"type": "mrkdwn", "text": {{json .EntityID}}}}]`
+	if err := os.WriteFile(filepath.Join(dir, "ticket_escalated.json.tmpl"), []byte(tmpl), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	stub := newSlackStub(t, slackOK)
+	c := NewSlack(stub.URL, "xoxb-test", "#oncall", nil, dir)
+	if err := c.Deliver(context.Background(), slackTask()); err != nil {
+		t.Fatalf("Deliver: %v", err)
+	}
+
+	blocks, _ := stub.last["blocks"].([]any)
+	if len(blocks) != 1 {
+		t.Fatalf("got %d blocks, want 1", len(blocks))
+	}
+	section, _ := blocks[0].(map[string]any)
+	text, _ := section["text"].(map[string]any)
+	if text["text"] != "TICKET-1" {
+		t.Errorf("section text = %v, want TICKET-1", text["text"])
+	}
+}
+
+func TestSlackInvalidTemplateIsPermanent(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "ticket_escalated.json.tmpl"), []byte(`[{"type": `), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	stub := newSlackStub(t, slackOK)
+	c := NewSlack(stub.URL, "xoxb-test", "#oncall", nil, dir)
+	err := c.Deliver(context.Background(), slackTask())
+	if err == nil || !IsPermanent(err) {
+		t.Fatalf("Deliver = %v, want a permanent error", err)
+	}
+	if stub.hits.Load() != 0 {
+		t.Errorf("invalid template was posted")
+	}
+}
+
+func TestSlackResponses(t *testing.T) {
+	tests := []struct {
+		name      string
+		respond   func(w http.ResponseWriter)
+		permanent bool
+		retryIn   time.Duration // 0 = no Retry-After expected
+	}{
+		{
+			name: "429 with Retry-After",
+			respond: func(w http.ResponseWriter) {
+				w.Header().Set("Retry-After", "7")
+				w.WriteHeader(http.StatusTooManyRequests)
+			},
+			retryIn: 7 * time.Second,
+		},
+		{
+			name:    "500 is retried",
+			respond: func(w http.ResponseWriter) { w.WriteHeader(http.StatusInternalServerError) },
+		},
+		{
+			name:      "403 is permanent",
+			respond:   func(w http.ResponseWriter) { w.WriteHeader(http.StatusForbidden) },
+			permanent: true,
+		},
+		{
+			name: "ok:false channel_not_found is permanent",
+			respond: func(w http.ResponseWriter) {
+				_, _ = io.WriteString(w, `{"ok": false, "error": "channel_not_found"}`)
+			},
+			permanent: true,
+		},
+		{
+			name: "ok:false ratelimited is retried after Retry-After",
+			respond: func(w http.ResponseWriter) {
+				w.Header().Set("Retry-After", "3")
+				_, _ = io.WriteString(w, `{"ok": false, "error": "ratelimited"}`)
+			},
+			retryIn: 3 * time.Second,
+		},
+		{
+			name: "ok:false internal_error is retried",
+			respond: func(w http.ResponseWriter) {
+				_, _ = io.WriteString(w, `{"ok": false, "error": "internal_error"}`)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newSlackStub(t, tt.respond)
+			c := NewSlack(stub.URL, "xoxb-test", "#oncall", nil, "")
+
+			err := c.Deliver(context.Background(), slackTask())
+			if err == nil {
+				t.Fatal("Deliver succeeded, want an error")
+			}
+			if IsPermanent(err) != tt.permanent {
+				t.Errorf("IsPermanent(%v) = %v, want %v", err, IsPermanent(err), tt.permanent)
+			}
+			d, ok := RetryDelay(err)
+			if tt.retryIn > 0 && (!ok || d != tt.retryIn) {
+				t.Errorf("RetryDelay = %v, %v; want %v", d, ok, tt.retryIn)
+			}
+			if tt.retryIn == 0 && ok {
+				t.Errorf("RetryDelay = %v, want none", d)
+			}
+		})
+	}
+}
+
+func TestSlackIncomingWebhooks(t *testing.T) {
+	stub := newSlackStub(t, func(w http.ResponseWriter) { _, _ = io.WriteString(w, "ok") })
+	hook := stub.URL + "/services/T000/B000/XXXX"
+	c := NewSlack(stub.URL, "", "", map[string]string{"ticket_escalated": hook}, "")
+
+	// Routed by event type to a configured webhook
+	if err := c.Deliver(context.Background(), slackTask()); err != nil {
+		t.Fatalf("Deliver: %v", err)
+	}
+	if stub.hits.Load() != 1 || stub.last["channel"] != nil {
+		t.Errorf("hits = %d, body = %v; want one post without a channel", stub.hits.Load(), stub.last)
+	}
+
+	// The same URL as an explicit recipient is allowed
+	task := slackTask()
+	task.Recipient = hook
+	if err := c.Deliver(context.Background(), task); err != nil {
+		t.Fatalf("Deliver to configured recipient URL: %v", err)
+	}
+
+	// Any other URL is refused without a request
+	task.Recipient = stub.URL + "/elsewhere"
+	err := c.Deliver(context.Background(), task)
+	if err == nil || !IsPermanent(err) {
+		t.Fatalf("Deliver to unconfigured URL = %v, want a permanent error", err)
+	}
+	if stub.hits.Load() != 2 {
+		t.Errorf("unconfigured URL was posted to")
+	}
+}
+
+func TestSlackNeedsBotTokenForChannels(t *testing.T) {
+	stub := newSlackStub(t, slackOK)
+	c := NewSlack(stub.URL, "", "#oncall", nil, "")
+
+	err := c.Deliver(context.Background(), slackTask())
+	if err == nil || !IsPermanent(err) {
+		t.Fatalf("Deliver = %v, want a permanent error", err)
+	}
+	if stub.hits.Load() != 0 {
+		t.Errorf("posted without a bot token")
+	}
+}
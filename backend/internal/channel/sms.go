@@ -0,0 +1,109 @@
+package channel
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"safe-notify/internal/models"
+)
+
+// SMSProvider sends one (possibly multi-part) text message.
+// Implementations return Permanent / RetryAfter errors like any Channel.
+type SMSProvider interface {
+	SendSMS(ctx context.Context, to, body string) error
+}
+
+// SMS renders a short text and hands it to a provider. Bodies longer than
+// MaxSegments concatenated segments are truncated rather than rejected.
+type SMS struct {
+	Provider    SMSProvider
+	MaxSegments int
+}
+
+func (c *SMS) Deliver(ctx context.Context, task models.Task) error {
+	if !strings.HasPrefix(task.To(), "+") {
+		return Permanent(fmt.Errorf("sms recipient %q is not E.164", task.To()))
+	}
+
+	body := fmt.Sprintf("[%s] %s %s", task.Priority, task.EventType, task.EntityID)
+	body = TruncateSMS(body, c.MaxSegments)
+
+	return c.Provider.SendSMS(ctx, task.To(), body)
+}
+
+// Segment limits per GSM 03.38: a single message holds 160 GSM-7 or 70 UCS-2
+// characters; concatenated parts lose room to the UDH header.
+const (
+	gsmSingle  = 160
+	gsmMulti   = 153
+	ucs2Single = 70
+	ucs2Multi  = 67
+)
+
+const gsmBasic = "@£$¥èéùìòÇ\nØø\rÅåΔ_ΦΓΛΩΠΨΣΘΞÆæßÉ !\"#¤%&'()*+,-./0123456789:;<=>?" +
+	"¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ§¿abcdefghijklmnopqrstuvwxyzäöñüà"
+
+const gsmExtended = "^{}\\[~]|€\f" // each costs an escape + the char
+
+// SegmentSMS splits body into the parts a carrier would deliver and reports
+// the encoding that will be used ("GSM-7" or "UCS-2").
+func SegmentSMS(body string) (parts []string, encoding string) {
+	encoding = "GSM-7"
+	for _, r := range body {
+		if !strings.ContainsRune(gsmBasic, r) && !strings.ContainsRune(gsmExtended, r) {
+			encoding = "UCS-2"
+			break
+		}
+	}
+
+	single, multi := gsmSingle, gsmMulti
+	if encoding == "UCS-2" {
+		single, multi = ucs2Single, ucs2Multi
+	}
+	if smsUnits(body, encoding) <= single {
+		return []string{body}, encoding
+	}
+
+	var cur strings.Builder
+	used := 0
+	for _, r := range body {
+		n := smsUnits(string(r), encoding)
+		if used+n > multi {
+			parts = append(parts, cur.String())
+			cur.Reset()
+			used = 0
+		}
+		cur.WriteRune(r)
+		used += n
+	}
+	if cur.Len() > 0 {
+		parts = append(parts, cur.String())
+	}
+	return parts, encoding
+}
+
+// smsUnits counts septets (GSM-7) or UTF-16 code units (UCS-2).
+func smsUnits(s, encoding string) int {
+	n := 0
+	for _, r := range s {
+		switch {
+		case encoding == "UCS-2" && r > 0xFFFF:
+			n += 2 // surrogate pair
+		case encoding == "GSM-7" && strings.ContainsRune(gsmExtended, r):
+			n += 2
+		default:
+			n++
+		}
+	}
+	return n
+}
+
+// TruncateSMS keeps at most maxSegments parts (0 = unlimited).
+func TruncateSMS(body string, maxSegments int) string {
+	parts, _ := SegmentSMS(body)
+	if maxSegments <= 0 || len(parts) <= maxSegments {
+		return body
+	}
+	return strings.Join(parts[:maxSegments], "")
+}
@@ -0,0 +1,47 @@
+package channel
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+)
+
+// FakeSMS records messages instead of sending them. Use it locally
+// (SMS_PROVIDER=fake) and in tests; set FailWith to simulate provider errors.
+type FakeSMS struct {
+	mu       sync.Mutex
+	Sent     []FakeSMSMessage
+	FailWith error
+}
+
+type FakeSMSMessage struct {
+	To       string
+	Body     string
+	Parts    []string
+	Encoding string
+}
+
+func (p *FakeSMS) SendSMS(ctx context.Context, to, body string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.FailWith != nil {
+		return p.FailWith
+	}
+	if to == "" {
+		return Permanent(errors.New("fake sms: empty recipient"))
+	}
+
+	parts, enc := SegmentSMS(body)
+	p.Sent = append(p.Sent, FakeSMSMessage{To: to, Body: body, Parts: parts, Encoding: enc})
+	log.Printf("fake sms: to=%s parts=%d encoding=%s body=%q", to, len(parts), enc, body)
+	return nil
+}
+
+// Messages returns a copy of everything sent so far.
+func (p *FakeSMS) Messages() []FakeSMSMessage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]FakeSMSMessage(nil), p.Sent...)
+}
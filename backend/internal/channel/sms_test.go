@@ -0,0 +1,132 @@
+package channel
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"safe-notify/internal/models"
+)
+
+func TestSegmentSMS(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		encoding string
+		parts    []int // rune count of each part
+	}{
+		{"GSM-7 single at limit", strings.Repeat("a", 160), "GSM-7", []int{160}},
+		{"GSM-7 one over splits at 153", strings.Repeat("a", 161), "GSM-7", []int{153, 8}},
+		{"GSM-7 two full parts", strings.Repeat("a", 306), "GSM-7", []int{153, 153}},
+		{"GSM-7 third part", strings.Repeat("a", 307), "GSM-7", []int{153, 153, 1}},
+		{"extended chars cost two septets", strings.Repeat("€", 80), "GSM-7", []int{80}},
+		{"extended chars over the limit", strings.Repeat("€", 81), "GSM-7", []int{76, 5}},
+		{"escape pair is never split", strings.Repeat("a", 152) + "€" + strings.Repeat("a", 10), "GSM-7", []int{152, 11}},
+		{"UCS-2 single at limit", strings.Repeat("ж", 70), "UCS-2", []int{70}},
+		{"UCS-2 one over splits at 67", strings.Repeat("ж", 71), "UCS-2", []int{67, 4}},
+		{"one non-GSM char switches encoding", strings.Repeat("a", 100) + "ж", "UCS-2", []int{67, 34}},
+		{"surrogate pairs count twice", strings.Repeat("😀", 35), "UCS-2", []int{35}},
+		{"surrogate pairs over the limit", strings.Repeat("😀", 36), "UCS-2", []int{33, 3}},
+		{"empty", "", "GSM-7", []int{0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts, enc := SegmentSMS(tt.body)
+			if enc != tt.encoding {
+				t.Errorf("encoding = %s, want %s", enc, tt.encoding)
+			}
+			got := make([]int, len(parts))
+			for i, p := range parts {
+				got[i] = len([]rune(p))
+			}
+			if !equalInts(got, tt.parts) {
+				t.Errorf("part lengths = %v, want %v", got, tt.parts)
+			}
+			if strings.Join(parts, "") != tt.body {
+				t.Errorf("parts don't add up to the body")
+			}
+		})
+	}
+}
+
+func TestTruncateSMS(t *testing.T) {
+	long := strings.Repeat("a", 400) // 3 parts
+
+	if got := TruncateSMS(long, 2); got != strings.Repeat("a", 306) {
+		t.Errorf("TruncateSMS(400, 2) kept %d chars, want 306", len(got))
+	}
+	if got := TruncateSMS(long, 3); got != long {
+		t.Errorf("TruncateSMS(400, 3) changed a body that fits")
+	}
+	if got := TruncateSMS(long, 0); got != long {
+		t.Errorf("TruncateSMS(400, 0) truncated; 0 means unlimited")
+	}
+	if got := TruncateSMS(strings.Repeat("ж", 100), 1); got != strings.Repeat("ж", 67) {
+		t.Errorf("TruncateSMS(UCS-2 100, 1) kept %d runes, want 67", len([]rune(got)))
+	}
+}
+
+func TestSMSDeliver(t *testing.T) {
+	task := models.Task{
+		TaskID:    "task_1",
+		EventType: "ticket_escalated",
+		EntityID:  "TICKET-1",
+		Channel:   "SMS",
+		Recipient: "+14155550100",
+		Priority:  "HIGH",
+	}
+
+	fake := &FakeSMS{}
+	c := &SMS{Provider: fake, MaxSegments: 1}
+	if err := c.Deliver(context.Background(), task); err != nil {
+		t.Fatalf("Deliver: %v", err)
+	}
+	sent := fake.Messages()
+	if len(sent) != 1 {
+		t.Fatalf("sent %d messages, want 1", len(sent))
+	}
+	if sent[0].To != "+14155550100" || sent[0].Body != "[HIGH] ticket_escalated TICKET-1" || sent[0].Encoding != "GSM-7" {
+		t.Errorf("sent %+v", sent[0])
+	}
+
+	// A long entity ID is cut to MaxSegments: the first 153-septet part,
+	// where "[" and "]" take two septets each
+	task.EntityID = strings.Repeat("x", 400)
+	if err := c.Deliver(context.Background(), task); err != nil {
+		t.Fatalf("Deliver: %v", err)
+	}
+	if last := fake.Messages()[1]; len(last.Parts) != 1 || len(last.Body) != 151 {
+		t.Errorf("long body: %d parts, %d chars; want 1 part of 151", len(last.Parts), len(last.Body))
+	}
+
+	// Non-E.164 recipients never reach the provider
+	task.Recipient = "4155550100"
+	if err := c.Deliver(context.Background(), task); !IsPermanent(err) {
+		t.Errorf("non-E.164 recipient: err = %v, want permanent", err)
+	}
+	if len(fake.Messages()) != 2 {
+		t.Errorf("non-E.164 recipient was sent")
+	}
+
+	// Provider errors pass through unchanged
+	boom := errors.New("provider down")
+	fake.FailWith = boom
+	task.Recipient = "+14155550100"
+	if err := c.Deliver(context.Background(), task); !errors.Is(err, boom) {
+		t.Errorf("err = %v, want %v", err, boom)
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,89 @@
+package channel
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TwilioSMS talks to the Twilio Messages API (or anything shaped like it).
+type TwilioSMS struct {
+	BaseURL    string // https://api.twilio.com
+	AccountSID string
+	AuthToken  string
+	From       string // E.164 sender, or a Messaging Service SID (MG...)
+	Client     *http.Client
+}
+
+func NewTwilioSMS(baseURL, accountSID, authToken, from string) *TwilioSMS {
+	if baseURL == "" {
+		baseURL = "https://api.twilio.com"
+	}
+	return &TwilioSMS{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		AccountSID: accountSID,
+		AuthToken:  authToken,
+		From:       from,
+		Client:     &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// twilioPermanent are Twilio error codes that will never succeed on retry
+// (invalid/unreachable number, recipient opted out via STOP, ...).
+var twilioPermanent = map[int]bool{
+	21211: true, // invalid To
+	21214: true, // To cannot be reached
+	21408: true, // region not enabled
+	21610: true, // recipient replied STOP
+	21612: true, // unsupported route
+	21614: true, // not a mobile number
+}
+
+func (p *TwilioSMS) SendSMS(ctx context.Context, to, body string) error {
+	form := url.Values{}
+	form.Set("To", to)
+	form.Set("Body", body)
+	if strings.HasPrefix(p.From, "MG") {
+		form.Set("MessagingServiceSid", p.From)
+	} else {
+		form.Set("From", p.From)
+	}
+
+	endpoint := fmt.Sprintf("%s/2010-04-01/Accounts/%s/Messages.json", p.BaseURL, url.PathEscape(p.AccountSID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(p.AccountSID, p.AuthToken)
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sms post failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		return nil
+	}
+
+	var apiErr struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&apiErr)
+	if twilioPermanent[apiErr.Code] {
+		return Permanent(fmt.Errorf("sms provider error %d: %s", apiErr.Code, apiErr.Message))
+	}
+
+	err = classifyHTTP("sms provider", resp.StatusCode, resp.Header)
+	if apiErr.Message != "" {
+		// %w keeps the Permanent/RetryAfter classification
+		err = fmt.Errorf("%w: %s", err, apiErr.Message)
+	}
+	return err
+}
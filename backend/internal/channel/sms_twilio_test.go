@@ -0,0 +1,110 @@
+package channel
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTwilioSendsForm(t *testing.T) {
+	var (
+		path string
+		form url.Values
+		user string
+		pass string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		user, pass, _ = r.BasicAuth()
+		_ = r.ParseForm()
+		form = r.PostForm
+		w.WriteHeader(http.StatusCreated)
+		_, _ = io.WriteString(w, `{"sid": "SM123"}`)
+	}))
+	defer srv.Close()
+
+	p := NewTwilioSMS(srv.URL, "AC123", "secret", "+15005550006")
+	if err := p.SendSMS(context.Background(), "+14155550100", "hello"); err != nil {
+		t.Fatalf("SendSMS: %v", err)
+	}
+	if path != "/2010-04-01/Accounts/AC123/Messages.json" {
+		t.Errorf("path = %s", path)
+	}
+	if user != "AC123" || pass != "secret" {
+		t.Errorf("basic auth = %s:%s", user, pass)
+	}
+	if form.Get("To") != "+14155550100" || form.Get("Body") != "hello" || form.Get("From") != "+15005550006" {
+		t.Errorf("form = %v", form)
+	}
+
+	// A Messaging Service SID goes in its own field
+	p.From = "MG999"
+	if err := p.SendSMS(context.Background(), "+14155550100", "hello"); err != nil {
+		t.Fatalf("SendSMS: %v", err)
+	}
+	if form.Get("MessagingServiceSid") != "MG999" || form.Get("From") != "" {
+		t.Errorf("form = %v", form)
+	}
+}
+
+func TestTwilioErrorClassification(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		retryAfter string
+		body       string
+		permanent  bool
+		retryIn    time.Duration
+		mentions   string
+	}{
+		{name: "invalid number", status: 400, body: `{"code": 21211, "message": "Invalid 'To' Phone Number"}`, permanent: true, mentions: "21211"},
+		{name: "recipient opted out", status: 400, body: `{"code": 21610, "message": "Attempt to send to unsubscribed recipient"}`, permanent: true, mentions: "21610"},
+		{name: "other 4xx", status: 400, body: `{"code": 12345, "message": "bad request"}`, permanent: true, mentions: "bad request"},
+		{name: "auth failure", status: 401, body: `{"code": 20003, "message": "Authenticate"}`, permanent: true},
+		{name: "rate limited", status: 429, retryAfter: "5", body: `{"code": 20429, "message": "Too Many Requests"}`, retryIn: 5 * time.Second},
+		{name: "server error", status: 500, body: `{"message": "Internal"}`, mentions: "Internal"},
+		{name: "unavailable without body", status: 503},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.retryAfter)
+				}
+				w.WriteHeader(tt.status)
+				_, _ = io.WriteString(w, tt.body)
+			}))
+			defer srv.Close()
+
+			err := NewTwilioSMS(srv.URL, "AC123", "secret", "+15005550006").SendSMS(context.Background(), "+14155550100", "hello")
+			if err == nil {
+				t.Fatal("SendSMS succeeded, want an error")
+			}
+			if IsPermanent(err) != tt.permanent {
+				t.Errorf("IsPermanent(%v) = %v, want %v", err, IsPermanent(err), tt.permanent)
+			}
+			if d, ok := RetryDelay(err); tt.retryIn > 0 && (!ok || d != tt.retryIn) {
+				t.Errorf("RetryDelay = %v, %v; want %v", d, ok, tt.retryIn)
+			}
+			if tt.mentions != "" && !strings.Contains(err.Error(), tt.mentions) {
+				t.Errorf("error %q doesn't mention %q", err, tt.mentions)
+			}
+		})
+	}
+}
+
+func TestTwilioUnreachable(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	srv.Close() // nothing listening
+
+	err := NewTwilioSMS(srv.URL, "AC123", "secret", "+15005550006").SendSMS(context.Background(), "+14155550100", "hello")
+	if err == nil || IsPermanent(err) {
+		t.Errorf("err = %v, want a retryable error", err)
+	}
+}
@@ -0,0 +1,92 @@
+package channel
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"safe-notify/internal/models"
+	"safe-notify/internal/webhook"
+)
+
+// Webhook POSTs a JSON rendering of the notification to a fixed URL.
+type Webhook struct {
+	URL     string
+	Secret  string            // HMAC key; empty = unsigned
+	Headers map[string]string // sent on every request
+	Client  *http.Client      // carries the timeout
+}
+
+// WebhookPayload is the JSON body of a WEBHOOK channel delivery.
+type WebhookPayload struct {
+	TaskID         string `json:"task_id"`
+	IdempotencyKey string `json:"idempotency_key"`
+	EventType      string `json:"event_type"`
+	EntityID       string `json:"entity_id"`
+	Priority       string `json:"priority"`
+	Recipient      string `json:"recipient,omitempty"`
+	Attempt        int    `json:"attempt"`
+	CreatedAt      int64  `json:"created_at"`
+}
+
+func NewWebhook(url, secret string, headers map[string]string, timeout time.Duration) *Webhook {
+	return &Webhook{
+		URL:     url,
+		Secret:  secret,
+		Headers: headers,
+		Client:  &http.Client{Timeout: timeout},
+	}
+}
+
+func (c *Webhook) Deliver(ctx context.Context, task models.Task) error {
+	body, err := json.Marshal(WebhookPayload{
+		TaskID:         task.TaskID,
+		IdempotencyKey: task.IdempotencyKey,
+		EventType:      task.EventType,
+		EntityID:       task.EntityID,
+		Priority:       task.Priority,
+		Recipient:      task.To(),
+		Attempt:        task.AttemptCount + 1,
+		CreatedAt:      task.CreatedAt,
+	})
+	if err != nil {
+		return Permanent(err)
+	}
+
+	headers := map[string]string{"Idempotency-Key": task.IdempotencyKey}
+	for k, v := range c.Headers {
+		headers[k] = v
+	}
+
+	code, h, err := webhook.Post(ctx, c.Client, webhook.Request{
+		URL:     c.URL,
+		Body:    body,
+		Secret:  c.Secret,
+		Headers: headers,
+	})
+	if err != nil {
+		return fmt.Errorf("webhook post failed: %w", err)
+	}
+	return classifyHTTP("webhook", code, h)
+}
+
+// classifyHTTP maps a provider's HTTP status onto the worker's retry rules:
+// 2xx delivered, 408/429/5xx retryable (honouring Retry-After), other 4xx permanent.
+func classifyHTTP(provider string, code int, h http.Header) error {
+	if code >= 200 && code <= 299 {
+		return nil
+	}
+
+	err := fmt.Errorf("%s returned HTTP %d", provider, code)
+	switch {
+	case code == http.StatusRequestTimeout, code == http.StatusTooManyRequests, code >= 500:
+		if d, ok := webhook.ParseRetryAfter(h); ok {
+			return RetryAfter(err, d)
+		}
+		return err
+	default:
+		return Permanent(err)
+	}
+}
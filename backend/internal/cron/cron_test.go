@@ -0,0 +1,140 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func mustLoad(t *testing.T, tz string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		t.Skipf("timezone %s not available: %v", tz, err)
+	}
+	return loc
+}
+
+func TestParseRejects(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"* * * FOO *",
+		"@fortnightly",
+	} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", expr)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	ny := mustLoad(t, "America/New_York")
+	at := func(y int, m time.Month, d, h, min int) time.Time {
+		return time.Date(y, m, d, h, min, 0, 0, ny)
+	}
+
+	tests := []struct {
+		name  string
+		expr  string
+		after time.Time
+		want  time.Time
+	}{
+		{"strictly after", "0 9 * * *", at(2026, 3, 2, 9, 0), at(2026, 3, 3, 9, 0)},
+		{"seconds are truncated", "0 9 * * *", at(2026, 3, 2, 8, 59).Add(30 * time.Second), at(2026, 3, 2, 9, 0)},
+		{"step list", "*/20 9-10 * * *", at(2026, 3, 2, 9, 40), at(2026, 3, 2, 10, 0)},
+		{"7 is Sunday", "0 0 * * 7", at(2026, 3, 2, 0, 0), at(2026, 3, 8, 0, 0)},
+		{"macro", "@monthly", at(2026, 3, 2, 0, 0), at(2026, 4, 1, 0, 0)},
+		{"no match", "0 0 30 2 *", at(2026, 1, 1, 0, 0), time.Time{}},
+
+		// 2026-03-08 02:00 EST jumps to 03:00 EDT; 2026-11-01 02:00 EDT falls back to 01:00 EST
+		{"spring forward keeps wall clock", "0 9 * * *", at(2026, 3, 7, 9, 0), at(2026, 3, 8, 9, 0)},
+		{"spring forward skips the missing time", "30 2 * * *", at(2026, 3, 7, 3, 0), at(2026, 3, 9, 2, 30)},
+		{"hourly across spring forward", "0 * * * *", at(2026, 3, 8, 1, 30), at(2026, 3, 8, 3, 0)},
+		{"fall back first 1:30", "30 1 * * *", at(2026, 11, 1, 0, 0), time.Date(2026, 11, 1, 5, 30, 0, 0, time.UTC)},
+		{"fall back runs the repeated hour once", "30 1 * * *", time.Date(2026, 11, 1, 5, 30, 0, 0, time.UTC).In(ny), at(2026, 11, 2, 1, 30)},
+
+		// 2026-02-06 and 2026-02-13 are Fridays; 2026-02-10 is a Tuesday
+		{"dom and dow both restricted: either matches (dow)", "0 0 10 * FRI", at(2026, 2, 1, 0, 0), at(2026, 2, 6, 0, 0)},
+		{"dom and dow both restricted: either matches (dom)", "0 0 10 * FRI", at(2026, 2, 6, 0, 0), at(2026, 2, 10, 0, 0)},
+		{"dow star: dom only", "0 0 10 * *", at(2026, 2, 1, 0, 0), at(2026, 2, 10, 0, 0)},
+		{"dom star: dow only", "0 0 * * FRI", at(2026, 2, 6, 0, 0), at(2026, 2, 13, 0, 0)},
+		{"dom step counts as star", "0 0 */2 * FRI", at(2026, 2, 1, 0, 0), at(2026, 2, 13, 0, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.expr, err)
+			}
+			got := s.Next(tt.after)
+			if !got.Equal(tt.want) {
+				t.Errorf("Next(%v) = %v, want %v", tt.after, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextRunBounds(t *testing.T) {
+	ms := func(s string) int64 {
+		t.Helper()
+		v, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v.UnixMilli()
+	}
+
+	tests := []struct {
+		name              string
+		after, start, end int64
+		want              int64
+	}{
+		{"no bounds", ms("2026-03-02T10:00:00Z"), 0, 0, ms("2026-03-03T09:00:00Z")},
+		{"start on an occurrence counts", ms("2026-03-01T00:00:00Z"), ms("2026-03-10T09:00:00Z"), 0, ms("2026-03-10T09:00:00Z")},
+		{"start between occurrences", ms("2026-03-01T00:00:00Z"), ms("2026-03-10T09:30:00Z"), 0, ms("2026-03-11T09:00:00Z")},
+		{"start already passed", ms("2026-03-12T10:00:00Z"), ms("2026-03-10T09:00:00Z"), 0, ms("2026-03-13T09:00:00Z")},
+		{"end on an occurrence counts", ms("2026-03-02T10:00:00Z"), 0, ms("2026-03-03T09:00:00Z"), ms("2026-03-03T09:00:00Z")},
+		{"next is after end", ms("2026-03-02T10:00:00Z"), 0, ms("2026-03-03T08:59:59Z"), 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NextRun("0 9 * * *", "UTC", tt.after, tt.start, tt.end)
+			if err != nil {
+				t.Fatalf("NextRun: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("NextRun = %v, want %v", time.UnixMilli(got).UTC(), time.UnixMilli(tt.want).UTC())
+			}
+		})
+	}
+}
+
+func TestNextRunInTimezone(t *testing.T) {
+	mustLoad(t, "Asia/Tokyo")
+	after := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC).UnixMilli()
+
+	// 09:00 in Tokyo (UTC+9) is 00:00 UTC, so the first one after midnight UTC is the next day
+	got, err := NextRun("0 9 * * *", "Asia/Tokyo", after, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC).UnixMilli(); got != want {
+		t.Errorf("NextRun = %v, want %v", time.UnixMilli(got).UTC(), time.UnixMilli(want).UTC())
+	}
+
+	if _, err := NextRun("0 9 * * *", "Mars/Olympus", after, 0, 0); err == nil {
+		t.Error("unknown timezone accepted")
+	}
+	if _, err := NextRun("0 9 * *", "UTC", after, 0, 0); err == nil {
+		t.Error("bad expression accepted")
+	}
+}
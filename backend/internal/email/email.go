@@ -10,8 +10,16 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/sesv2/types"
 )
 
+type Message struct {
+	From    string // overrides the sender's default From address, e.g. a tenant's identity
+	To      string
+	Subject string
+	Body    string
+	Headers map[string]string // extra headers, e.g. List-Unsubscribe
+}
+
 type Sender interface {
-	Send(ctx context.Context, to string, subject string, body string) error
+	Send(ctx context.Context, msg Message) error
 }
 
 type SESSender struct {
@@ -30,18 +38,29 @@ func NewSESSender(cfg aws.Config) (*SESSender, error) {
 	}, nil
 }
 
-func (s *SESSender) Send(ctx context.Context, to, subject, body string) error {
+func (s *SESSender) Send(ctx context.Context, msg Message) error {
+	headers := make([]types.MessageHeader, 0, len(msg.Headers))
+	for k, v := range msg.Headers {
+		headers = append(headers, types.MessageHeader{Name: aws.String(k), Value: aws.String(v)})
+	}
+
+	from := s.fromEmail
+	if msg.From != "" {
+		from = msg.From
+	}
+
 	_, err := s.client.SendEmail(ctx, &sesv2.SendEmailInput{
-		FromEmailAddress: aws.String(s.fromEmail),
+		FromEmailAddress: aws.String(from),
 		Destination: &types.Destination{
-			ToAddresses: []string{to},
+			ToAddresses: []string{msg.To},
 		},
 		Content: &types.EmailContent{
 			Simple: &types.Message{
-				Subject: &types.Content{Data: aws.String(subject)},
+				Subject: &types.Content{Data: aws.String(msg.Subject)},
 				Body: &types.Body{
-					Text: &types.Content{Data: aws.String(body)},
+					Text: &types.Content{Data: aws.String(msg.Body)},
 				},
+				Headers: headers,
 			},
 		},
 	})
@@ -10,10 +10,28 @@ package httpapi
 
 import (
 	kafkaproducer "safe-notify/internal/queue"
+	"safe-notify/internal/ratelimit"
 	"safe-notify/internal/store"
+	"safe-notify/internal/tenant"
+	"safe-notify/internal/unsubscribe"
 )
 
 type App struct {
 	Store         *store.DynamoStore
-	TasksProducer *kafkaproducer.Producer // publishes to safe-notify-tasks
+	TasksProducer *kafkaproducer.LaneProducer // publishes to the task's priority lane
+	SNSToken      string                      // shared secret expected on SES/SNS callbacks; empty disables them
+	Unsubscribe   *unsubscribe.Signer         // nil disables /unsubscribe
+	AdminAPIKey   string                      // bootstrap key with the admin scope; empty disables it
+
+	DefaultCountryCode string                           // prefix for SMS numbers given without one, e.g. "1"
+	FallbackChains     map[string][]FallbackStepRequest // event_type -> default escalation chain
+	DigestWindows      map[string]int                   // event_type -> digest window in seconds
+	ThrottleRules      map[string]ThrottleRule          // event_type -> send cap per recipient
+	Tenants            tenant.Registry                  // per-tenant overrides (max attempts, ...)
+
+	RequestLimits     ratelimit.RouteLimits // inbound limits by route; "*" is the default
+	RequestCounters   ratelimit.Counters    // in memory or shared through the store; nil disables limits
+	TrustForwardedFor bool                  // take the client IP from X-Forwarded-For (behind a proxy)
+
+	AllowPrivateWebhooks bool // accept callback URLs on localhost/private networks (local development)
 }
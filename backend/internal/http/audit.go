@@ -0,0 +1,128 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"safe-notify/internal/models"
+)
+
+// auditReasonHeader carries the caller's reason for a change, e.g. the
+// incident or ticket it belongs to. It is optional and free text.
+const auditReasonHeader = "X-Audit-Reason"
+
+// audit records that the caller changed target from before to after (nil
+// for a target created or deleted). Call it once the change has been
+// applied. A failed write is logged; the change itself stands.
+func (a *App) audit(r *http.Request, action, targetType, targetID string, before, after any) {
+	rec := newAuditRecord(r, tenantOf(r), action, targetType, targetID, before, after)
+	if k := APIKeyFromContext(r.Context()); k != nil {
+		rec.Actor, rec.ActorName, rec.ActorRole = k.KeyID, k.Name, k.Role
+	}
+	a.putAudit(r, rec)
+}
+
+// auditSystem is audit for changes made without an API key: actor names
+// where they came from, e.g. "ses" for bounce feedback or "unsubscribe"
+// for a recipient's unsubscribe link.
+func (a *App) auditSystem(r *http.Request, tenantID, actor, action, targetType, targetID string, before, after any) {
+	rec := newAuditRecord(r, tenantID, action, targetType, targetID, before, after)
+	rec.Actor, rec.ActorName = actor, actor
+	a.putAudit(r, rec)
+}
+
+func newAuditRecord(r *http.Request, tenantID, action, targetType, targetID string, before, after any) models.AuditRecord {
+	rec := models.AuditRecord{
+		AuditID:    "aud_" + randomHex(8),
+		TenantID:   tenantID,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Before:     auditSnapshot(before),
+		After:      auditSnapshot(after),
+		Reason:     strings.TrimSpace(r.Header.Get(auditReasonHeader)),
+		At:         time.Now().UnixMilli(),
+	}
+	if len(rec.Reason) > 500 {
+		rec.Reason = rec.Reason[:500]
+	}
+	return rec
+}
+
+func (a *App) putAudit(r *http.Request, rec models.AuditRecord) {
+	if err := a.Store.PutAuditRecord(r.Context(), rec); err != nil {
+		log.Println("api: audit write failed:", rec.Action, rec.TargetID, err)
+	}
+}
+
+func auditSnapshot(v any) json.RawMessage {
+	if v == nil {
+		return nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil || string(b) == "null" {
+		return nil
+	}
+	return b
+}
+
+// GET /admin/audit?actor=&action=&target=&from=&to=&limit=
+//
+// from and to are RFC 3339 times (to is exclusive). Results are newest
+// first. Admins see their own tenant's trail; the bootstrap key sees every
+// tenant's, or one with tenant=.
+func (a *App) listAuditHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	f := models.AuditFilter{
+		TenantID: tenantOf(r),
+		Actor:    q.Get("actor"),
+		Action:   q.Get("action"),
+		TargetID: q.Get("target"),
+	}
+	if isBootstrap(r) {
+		f.TenantID = q.Get("tenant")
+	} else if t := q.Get("tenant"); t != "" && t != f.TenantID {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "only the bootstrap key can read other tenants' audit logs"})
+		return
+	}
+
+	for _, p := range []struct {
+		name string
+		dst  *int64
+	}{{"from", &f.FromMs}, {"to", &f.ToMs}} {
+		v := q.Get(p.name)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": p.name + " must be RFC 3339"})
+			return
+		}
+		*p.dst = t.UnixMilli()
+	}
+
+	limit := 100
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > 1000 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "limit must be 1-1000"})
+			return
+		}
+		limit = n
+	}
+
+	items, err := a.Store.QueryAudit(r.Context(), f, limit)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load audit log"})
+		return
+	}
+	if items == nil {
+		items = []models.AuditRecord{}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": items})
+}
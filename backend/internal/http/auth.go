@@ -0,0 +1,248 @@
+package httpapi
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"safe-notify/internal/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type apiKeyCtx struct{}
+
+// bootstrapKeyID identifies requests made with App.AdminAPIKey. It acts as
+// the default tenant, and is the only key that may provision keys for other
+// tenants or manage the deployment-wide suppression list.
+const bootstrapKeyID = "bootstrap"
+
+type CreateAPIKeyRequest struct {
+	Name          string   `json:"name"`
+	TenantID      string   `json:"tenantId"`      // bootstrap key only; others create keys for their own tenant
+	Role          string   `json:"role"`          // viewer, operator or admin
+	Scopes        []string `json:"scopes"`        // extra scopes on top of the role
+	ExpiresAt     string   `json:"expiresAt"`     // optional, RFC 3339
+	ExpiresInDays int      `json:"expiresInDays"` // optional, ignored if expiresAt is set
+}
+
+// CreateAPIKeyResponse is the only time the full key is returned.
+type CreateAPIKeyResponse struct {
+	models.APIKey
+	Key string `json:"key"`
+}
+
+// APIKeyFromContext returns the key that authenticated the request, if any.
+func APIKeyFromContext(ctx context.Context) *models.APIKey {
+	k, _ := ctx.Value(apiKeyCtx{}).(*models.APIKey)
+	return k
+}
+
+// tenantOf is the tenant the request acts as. Every handler scopes its
+// reads and writes to it.
+func tenantOf(r *http.Request) string {
+	if k := APIKeyFromContext(r.Context()); k != nil {
+		return models.TenantOrDefault(k.TenantID)
+	}
+	return models.DefaultTenant
+}
+
+// isBootstrap reports whether the request was made with ADMIN_API_KEY, the
+// only key that may act across tenants. This is not the "operator" role.
+func isBootstrap(r *http.Request) bool {
+	k := APIKeyFromContext(r.Context())
+	return k != nil && k.KeyID == bootstrapKeyID
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// requestKey reads "Authorization: Bearer <key>" or "X-API-Key: <key>".
+func requestKey(r *http.Request) string {
+	if h := r.Header.Get("Authorization"); len(h) > 7 && strings.EqualFold(h[:7], "Bearer ") {
+		return strings.TrimSpace(h[7:])
+	}
+	return strings.TrimSpace(r.Header.Get("X-API-Key"))
+}
+
+// authenticate resolves a raw "sn_<key_id>_<secret>" key. It returns nil for
+// unknown, mismatched or expired keys.
+func (a *App) authenticate(ctx context.Context, raw string) (*models.APIKey, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	if a.AdminAPIKey != "" && subtle.ConstantTimeCompare([]byte(raw), []byte(a.AdminAPIKey)) == 1 {
+		return &models.APIKey{KeyID: bootstrapKeyID, Name: bootstrapKeyID, TenantID: models.DefaultTenant, Role: models.RoleAdmin}, nil
+	}
+
+	parts := strings.SplitN(raw, "_", 3)
+	if len(parts) != 3 || parts[0] != "sn" || parts[1] == "" || parts[2] == "" {
+		return nil, nil
+	}
+	k, err := a.Store.GetAPIKey(ctx, parts[1])
+	if err != nil || k == nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(hashSecret(parts[2])), []byte(k.SecretHash)) != 1 {
+		return nil, nil
+	}
+	if k.Expired(time.Now().UnixMilli()) {
+		return nil, nil
+	}
+	return k, nil
+}
+
+// requireScope rejects requests without a valid key holding scope (401 for a
+// missing or bad key, 403 for a missing scope). admin implies every scope.
+func (a *App) requireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			k, err := a.authenticate(r.Context(), requestKey(r))
+			if err != nil {
+				writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to verify API key"})
+				return
+			}
+			if k == nil {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="safe-notify"`)
+				writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "missing or invalid API key"})
+				return
+			}
+			if !k.HasScope(scope) {
+				writeJSON(w, http.StatusForbidden, map[string]string{"error": "API key lacks scope " + scope})
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), apiKeyCtx{}, k)))
+		})
+	}
+}
+
+// requireBootstrap limits a route to the bootstrap key. It runs after
+// requireScope, which has already authenticated the request.
+func (a *App) requireBootstrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isBootstrap(r) {
+			writeJSON(w, http.StatusForbidden, map[string]string{"error": "only the bootstrap admin key can do this"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// listAPIKeysHandler lists the caller's tenant's keys; the bootstrap key sees all.
+func (a *App) listAPIKeysHandler(w http.ResponseWriter, r *http.Request) {
+	keys, err := a.Store.ListAPIKeys(r.Context())
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load API keys"})
+		return
+	}
+	items := make([]models.APIKey, 0, len(keys))
+	for _, k := range keys {
+		if isBootstrap(r) || models.OwnedBy(k.TenantID, tenantOf(r)) {
+			items = append(items, k)
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": items})
+}
+
+func (a *App) createAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	var req CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON"})
+		return
+	}
+	if req.Name == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "name is required"})
+		return
+	}
+	if req.Role == "" && len(req.Scopes) == 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "role or scopes is required"})
+		return
+	}
+	if req.Role != "" && !models.ValidRole(req.Role) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "role must be viewer, operator or admin"})
+		return
+	}
+	for _, s := range req.Scopes {
+		if !models.ValidScope(s) {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "scopes must be from " + strings.Join(models.Scopes, ", ")})
+			return
+		}
+	}
+
+	tenantID := tenantOf(r)
+	if req.TenantID != "" && req.TenantID != tenantID {
+		if !isBootstrap(r) {
+			writeJSON(w, http.StatusForbidden, map[string]string{"error": "keys can only be created for your own tenant"})
+			return
+		}
+		if !models.ValidTenantID(req.TenantID) {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "tenantId must be 1-32 of a-z, 0-9, _ and -"})
+			return
+		}
+		tenantID = req.TenantID
+	}
+
+	now := time.Now()
+	var expiresAt int64
+	switch {
+	case req.ExpiresAt != "":
+		t, err := time.Parse(time.RFC3339, req.ExpiresAt)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "expiresAt must be RFC 3339"})
+			return
+		}
+		if !t.After(now) {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "expiresAt must be in the future"})
+			return
+		}
+		expiresAt = t.UnixMilli()
+	case req.ExpiresInDays < 0:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "expiresInDays must be positive"})
+		return
+	case req.ExpiresInDays > 0:
+		expiresAt = now.AddDate(0, 0, req.ExpiresInDays).UnixMilli()
+	}
+
+	secret := randomHex(32)
+	k := models.APIKey{
+		KeyID:      randomHex(8),
+		Name:       req.Name,
+		TenantID:   tenantID,
+		SecretHash: hashSecret(secret),
+		Role:       req.Role,
+		Scopes:     req.Scopes,
+		ExpiresAt:  expiresAt,
+		CreatedAt:  now.UnixMilli(),
+	}
+	if err := a.Store.PutAPIKey(r.Context(), k); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to store API key"})
+		return
+	}
+	a.audit(r, models.AuditAPIKeyCreate, "api_key", k.KeyID, nil, k)
+	writeJSON(w, http.StatusOK, CreateAPIKeyResponse{APIKey: k, Key: "sn_" + k.KeyID + "_" + secret})
+}
+
+func (a *App) deleteAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	k, err := a.Store.GetAPIKey(r.Context(), chi.URLParam(r, "key_id"))
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load API key"})
+		return
+	}
+	if k == nil || !(isBootstrap(r) || models.OwnedBy(k.TenantID, tenantOf(r))) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "API key not found"})
+		return
+	}
+	if err := a.Store.DeleteAPIKey(r.Context(), k.KeyID); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to delete API key"})
+		return
+	}
+	a.audit(r, models.AuditAPIKeyDelete, "api_key", k.KeyID, k, nil)
+	writeJSON(w, http.StatusOK, map[string]bool{"ok": true})
+}
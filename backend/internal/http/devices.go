@@ -0,0 +1,114 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"safe-notify/internal/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type RegisterDeviceRequest struct {
+	Token    string `json:"token"`
+	Platform string `json:"platform"` // ANDROID | IOS | WEB
+}
+
+var supportedPlatforms = map[string]bool{"ANDROID": true, "IOS": true, "WEB": true}
+
+func (a *App) listDevicesHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDParam(w, r)
+	if !ok {
+		return
+	}
+	devices, err := a.Store.ListDevices(r.Context(), tenantOf(r), userID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load devices"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": devices})
+}
+
+// registerDeviceHandler is an upsert: re-registering a token just refreshes it.
+func (a *App) registerDeviceHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	var req RegisterDeviceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON"})
+		return
+	}
+	req.Token = strings.TrimSpace(req.Token)
+	req.Platform = strings.ToUpper(req.Platform)
+	if req.Token == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "token required"})
+		return
+	}
+	if !supportedPlatforms[req.Platform] {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "platform must be ANDROID, IOS or WEB"})
+		return
+	}
+
+	now := time.Now().UnixMilli()
+	d := models.Device{
+		UserID:    userID,
+		TenantID:  tenantOf(r),
+		Token:     req.Token,
+		Platform:  req.Platform,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	before, err := a.findDevice(r.Context(), d.TenantID, userID, d.Token)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load devices"})
+		return
+	}
+	if err := a.Store.PutDevice(r.Context(), d); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to store device"})
+		return
+	}
+	a.audit(r, models.AuditDeviceRegister, "device", userID, before, d)
+	writeJSON(w, http.StatusOK, d)
+}
+
+func (a *App) deregisterDeviceHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDParam(w, r)
+	if !ok {
+		return
+	}
+	token := chi.URLParam(r, "token")
+
+	before, err := a.findDevice(r.Context(), tenantOf(r), userID, token)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load devices"})
+		return
+	}
+	if err := a.Store.DeleteDevice(r.Context(), tenantOf(r), userID, token); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to delete device"})
+		return
+	}
+	if before != nil {
+		a.audit(r, models.AuditDeviceDeregister, "device", userID, before, nil)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"ok": true})
+}
+
+// findDevice returns userID's device with token, or nil if there is none.
+func (a *App) findDevice(ctx context.Context, tenantID, userID, token string) (*models.Device, error) {
+	devices, err := a.Store.ListDevices(ctx, tenantID, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range devices {
+		if d.Token == token {
+			return &d, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,50 @@
+package httpapi
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"safe-notify/internal/models"
+)
+
+// digestFor returns the digest window task falls into, or nil if its event
+// type isn't batched. Windows are fixed per recipient: every task for the
+// same (tenant, channel, recipient, event type) in [start, start+window)
+// shares one.
+func (a *App) digestFor(task models.Task, nowMs int64) *models.Digest {
+	secs := a.DigestWindows[task.EventType]
+	if secs <= 0 {
+		return nil
+	}
+	windowMs := int64(secs) * 1000
+	start := nowMs - nowMs%windowMs
+
+	key := fmt.Sprintf("%s|%s|%s|%s|%d", models.TenantOrDefault(task.TenantID), task.Channel, task.Recipient, task.EventType, start)
+	sum := sha256.Sum256([]byte(key))
+	return &models.Digest{
+		DigestKey:    key,
+		DigestTaskID: "task_dig_" + hex.EncodeToString(sum[:10]),
+		TenantID:     task.TenantID,
+		Channel:      task.Channel,
+		Recipient:    task.Recipient,
+		EventType:    task.EventType,
+		ClosesAt:     start + windowMs,
+	}
+}
+
+// holdForDigest adds a BATCHED task to its window. If the window closed in
+// the meantime the task is reverted to PENDING so it is sent on its own.
+func (a *App) holdForDigest(ctx context.Context, task *models.Task, d *models.Digest) error {
+	ok, err := a.Store.AppendDigest(ctx, *d, models.DigestEntry{
+		TaskID:   task.TaskID,
+		EntityID: task.EntityID,
+		Priority: task.Priority,
+	})
+	if err != nil || ok {
+		return err
+	}
+	task.Status, task.DigestTaskID = "PENDING", ""
+	return a.Store.PutTask(ctx, *task)
+}
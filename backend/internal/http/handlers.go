@@ -1,13 +1,16 @@
 package httpapi
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"safe-notify/internal/models"
+	"safe-notify/internal/phone"
+	"strings"
 	"time"
 
 	"fmt"
-	"math/rand"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -15,15 +18,156 @@ import (
 type CreateEventRequest struct {
 	EventType        string `json:"eventType"`
 	EntityID         string `json:"entityId"`
-	RecipientEmail   string `json:"recipientEmail"`
+	RecipientEmail   string `json:"recipientEmail"` // legacy alias of Recipient for EMAIL
+	Recipient        string `json:"recipient"`      // email, phone (SMS), user ID (PUSH, IN_APP), Slack channel...
+	Channel          string `json:"channel"`        // EMAIL (default) | WEBHOOK | SLACK | SMS | PUSH | IN_APP
 	Priority         string `json:"priority"`
 	ChaosFailPercent int    `json:"chaosFailPercent"`
+	CallbackURL      string `json:"callbackUrl"` // optional: receives signed status webhooks
+
+	// Fan-out: Targets lists explicit (channel, recipient) pairs, e.g. email
+	// plus Slack. Recipients is shorthand for several recipients on Channel.
+	Targets    []EventTarget `json:"targets"`
+	Recipients []string      `json:"recipients"`
+
+	// Fallback overrides the per-event-type chain for targets without their own.
+	Fallback []FallbackStepRequest `json:"fallback"`
+
+	// SendAt schedules delivery: RFC 3339 ("2026-10-20T09:00:00-04:00"), or a
+	// local time ("2026-10-20T09:00") interpreted in Timezone (IANA, default UTC).
+	SendAt   string `json:"sendAt"`
+	Timezone string `json:"timezone"`
+}
+
+// EventTarget is one (channel, recipient) pair; each becomes its own task.
+type EventTarget struct {
+	Channel   string                `json:"channel"`
+	Recipient string                `json:"recipient"`
+	Fallback  []FallbackStepRequest `json:"fallback"`
+
+	steps []models.FallbackStep // resolved Fallback
+}
+
+// FallbackStepRequest is one escalation hop. An empty recipient reuses the
+// target's recipient (e.g. the same user ID for PUSH -> IN_APP).
+type FallbackStepRequest struct {
+	Channel      string `json:"channel"`
+	Recipient    string `json:"recipient"`
+	DelaySeconds int    `json:"delaySeconds"`
+}
+
+const (
+	maxTargetsPerEvent = 50
+	maxFallbackSteps   = 5
+	maxScheduleAhead   = 366 * 24 * time.Hour
+)
+
+var localSendAtLayouts = []string{"2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02 15:04"}
+
+// parseSendAt returns sendAt as epoch ms (0 if empty) and the timezone it used.
+func parseSendAt(sendAt, tz string) (int64, string, error) {
+	if sendAt == "" {
+		return 0, "", nil
+	}
+	if tz == "" {
+		tz = "UTC"
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return 0, "", fmt.Errorf("unknown timezone: %s", tz)
+	}
+
+	if t, err := time.Parse(time.RFC3339, sendAt); err == nil {
+		return t.UnixMilli(), tz, nil
+	}
+	for _, layout := range localSendAtLayouts {
+		if t, err := time.ParseInLocation(layout, sendAt, loc); err == nil {
+			return t.UnixMilli(), tz, nil
+		}
+	}
+	return 0, "", fmt.Errorf("sendAt must be RFC 3339 or YYYY-MM-DDTHH:MM[:SS] local time")
 }
 
 type CreateEventResponse struct {
+	EventID        string        `json:"event_id"`
+	TaskID         string        `json:"task_id"` // first task, for single-target callers
+	IdempotencyKey string        `json:"idempotency_key"`
+	EntityID       string        `json:"entity_id"`
+	SendAt         int64         `json:"send_at,omitempty"` // epoch ms, scheduled events only
+	Tasks          []CreatedTask `json:"tasks"`
+	QuotaWarning   string        `json:"quota_warning,omitempty"` // a soft quota has been passed
+}
+
+type CreatedTask struct {
 	TaskID         string `json:"task_id"`
 	IdempotencyKey string `json:"idempotency_key"`
-	EntityID       string `json:"entity_id"`
+	Channel        string `json:"channel"`
+	Recipient      string `json:"recipient"`
+	Status         string `json:"status"`
+	DigestTaskID   string `json:"digest_task_id,omitempty"`
+	Error          string `json:"error,omitempty"` // set when this task was not stored or queued
+}
+
+// supportedChannels are the values accepted for CreateEventRequest.Channel.
+// A worker still needs the matching adapter configured to deliver them.
+var supportedChannels = map[string]bool{
+	"EMAIL":   true,
+	"WEBHOOK": true,
+	"SLACK":   true,
+	"SMS":     true,
+	"PUSH":    true,
+	"IN_APP":  true,
+}
+
+// validRecordID reports whether id can key a preference, device or inbox
+// record. Those are stored under "<tenant>#<id>", and the default tenant's
+// under the bare ID, so a '#' inside an ID could name another tenant's
+// record. A leading one, as in a Slack channel, cannot.
+func validRecordID(id string) bool {
+	return !strings.Contains(strings.TrimPrefix(id, "#"), "#")
+}
+
+// userIDParam returns the {user_id} path parameter, or writes a 400 and
+// returns false if it can't key a record.
+func userIDParam(w http.ResponseWriter, r *http.Request) (string, bool) {
+	userID := chi.URLParam(r, "user_id")
+	if !validRecordID(userID) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "user_id may only contain # as its first character"})
+		return "", false
+	}
+	return userID, true
+}
+
+// normalizeTaskRecipient validates the address for the channel and returns
+// the form stored on the task (lowercased email, E.164 phone, ...).
+func (a *App) normalizeTaskRecipient(channel, recipient string) (string, error) {
+	recipient = strings.TrimSpace(recipient)
+	if !validRecordID(recipient) {
+		return "", fmt.Errorf("recipient may only contain # as its first character")
+	}
+	switch channel {
+	case "EMAIL":
+		if recipient == "" {
+			recipient = "demo@example.com"
+		}
+		if !strings.Contains(recipient, "@") {
+			return "", fmt.Errorf("invalid email recipient")
+		}
+		return strings.ToLower(recipient), nil
+	case "SMS":
+		e164, err := phone.NormalizeE164(recipient, a.DefaultCountryCode)
+		if err != nil {
+			return "", fmt.Errorf("recipient must be a phone number in E.164 format (e.g. +14155550100)")
+		}
+		return e164, nil
+	case "PUSH", "IN_APP":
+		if recipient == "" {
+			return "", fmt.Errorf("recipient (user ID) required for %s", channel)
+		}
+		return recipient, nil
+	default:
+		return recipient, nil
+	}
 }
 
 type ListNotificationsResponse struct {
@@ -41,7 +185,7 @@ func writeJSON(w http.ResponseWriter, status int, v any) {
 }
 
 func (a *App) listNotificationsHandler(w http.ResponseWriter, r *http.Request) {
-	tasks, err := a.Store.ListTasks(r.Context(), 50)
+	tasks, err := a.Store.ListTasks(r.Context(), tenantOf(r), 50)
 
 	if err != nil {
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load tasks"})
@@ -93,53 +237,328 @@ func (a *App) createEvent(w http.ResponseWriter, r *http.Request) {
 	if req.EntityID == "" {
 		req.EntityID = "TICKET-XXXX"
 	}
-	if req.RecipientEmail == "" {
-		req.RecipientEmail = "demo@example.com"
-	}
+	req.Priority = strings.ToUpper(req.Priority)
 	if req.Priority == "" {
 		req.Priority = "HIGH"
 	}
+	if !models.ValidPriority(req.Priority) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "priority must be one of " + strings.Join(models.Priorities, ", ")})
+		return
+	}
+	if req.CallbackURL != "" {
+		if err := a.checkWebhookURL(req.CallbackURL); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "callbackUrl " + err.Error()})
+			return
+		}
+	}
 
-	taskID := fmt.Sprintf("task_%04d", rand.Intn(10000))
-	channel := "EMAIL"
-	idKey := fmt.Sprintf("%s:%s:%s:%s", req.EventType, req.EntityID, channel, req.RecipientEmail)
+	targets, err := a.resolveTargets(req)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
 
 	now := time.Now().UnixMilli()
 
-	task := models.Task{
-		TaskID:           taskID,
-		IdempotencyKey:   idKey,
-		EventType:        req.EventType,
-		EntityID:         req.EntityID,
-		Channel:          channel,
-		RecipientEmail:   req.RecipientEmail,
-		Priority:         req.Priority,
-		Status:           "PENDING",
-		AttemptCount:     0,
-		MaxAttempts:      3,
-		LastError:        "",
-		ChaosFailPercent: req.ChaosFailPercent,
-		CreatedAt:        now,
-		UpdatedAt:        now,
+	sendAt, tz, err := parseSendAt(req.SendAt, req.Timezone)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if sendAt > now+maxScheduleAhead.Milliseconds() {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "sendAt is too far in the future"})
+		return
+	}
+	status := "PENDING"
+	if sendAt > now {
+		status = "SCHEDULED" // the scheduler releases it when due
+	} else {
+		sendAt = 0 // in the past: send now
+	}
+	tenantID := tenantOf(r)
+	preferred, err := a.preferredChannels(r.Context(), tenantID, targets)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load preferences"})
+		return
+	}
+	quota, err := a.reserveQuota(r.Context(), tenantID, len(targets), time.UnixMilli(now))
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to check quota"})
+		return
+	}
+	if quota.Exceeded {
+		writeQuotaExceeded(w, quota, time.UnixMilli(now))
+		return
+	}
+	event := models.Event{
+		EventID:   "evt_" + randomHex(8),
+		TenantID:  tenantID,
+		EventType: req.EventType,
+		EntityID:  req.EntityID,
+		Priority:  req.Priority,
+		CreatedAt: now,
+	}
+
+	tasks := make([]models.Task, 0, len(targets))
+	digests := map[string]*models.Digest{} // task_id -> window, BATCHED tasks only
+	for _, t := range targets {
+		task := models.Task{
+			TaskID:           "task_" + randomHex(6),
+			IdempotencyKey:   fmt.Sprintf("%s:%s:%s:%s", req.EventType, req.EntityID, t.Channel, t.Recipient),
+			EventID:          event.EventID,
+			TenantID:         tenantID,
+			EventType:        req.EventType,
+			EntityID:         req.EntityID,
+			Channel:          t.Channel,
+			Recipient:        t.Recipient,
+			Priority:         req.Priority,
+			CallbackURL:      req.CallbackURL,
+			Fallback:         t.steps,
+			SendAt:           sendAt,
+			Timezone:         tz,
+			Status:           status,
+			AttemptCount:     0,
+			MaxAttempts:      a.Tenants.MaxAttempts(tenantID),
+			LastError:        "",
+			ChaosFailPercent: req.ChaosFailPercent,
+			CreatedAt:        now,
+			UpdatedAt:        now,
+		}
+		if t.Channel == "EMAIL" {
+			task.RecipientEmail = t.Recipient // keep the legacy field populated for the UI
+		}
+		if p, ok := preferred[normalizeRecipient(t.Recipient)]; ok && p != t.Channel {
+			task.Status, task.LastError = "SUPPRESSED", "recipient prefers "+p // recorded, never sent
+		}
+		if task.Status == status {
+			limited, reason, err := a.throttled(r.Context(), task, now)
+			if err != nil {
+				a.releaseQuota(r.Context(), tenantID, len(targets), time.UnixMilli(now))
+				a.releaseThrottle(r.Context(), tasks, now)
+				writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to check throttle"})
+				return
+			}
+			if limited {
+				task.Status, task.LastError = "THROTTLED", reason // recorded, never sent
+			}
+		}
+		if task.Status == "PENDING" {
+			if d := a.digestFor(task, now); d != nil {
+				task.Status, task.DigestTaskID = "BATCHED", d.DigestTaskID
+				digests[task.TaskID] = d
+			}
+		}
+		tasks = append(tasks, task)
+		event.TaskIDs = append(event.TaskIDs, task.TaskID)
 	}
 
-	if err := a.Store.PutTask(r.Context(), task); err != nil {
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to store task"})
+	// Parent first, so children never point at a missing event
+	if err := a.Store.PutEvent(r.Context(), event); err != nil {
+		a.releaseQuota(r.Context(), tenantID, len(tasks), time.UnixMilli(now))
+		a.releaseThrottle(r.Context(), tasks, now)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to store event"})
 		return
 	}
 
-	// Publish work item to Kafka
-	if err := a.TasksProducer.PublishTask(r.Context(), taskID); err != nil {
-		http.Error(w, "failed to publish to kafka: "+err.Error(), http.StatusInternalServerError)
+	// Each task is stored and queued on its own. A failure is reported on
+	// that task instead of failing the request, so the caller can tell
+	// which targets went out and retry only the rest.
+	failed := map[string]string{} // task_id -> error
+	var unstored []models.Task
+	for i := range tasks {
+		if err := a.createTask(r.Context(), &tasks[i], digests[tasks[i].TaskID]); err != nil {
+			failed[tasks[i].TaskID] = err.Error()
+			unstored = append(unstored, tasks[i])
+		}
+	}
+	a.releaseQuota(r.Context(), tenantID, len(failed), time.UnixMilli(now))
+	a.releaseThrottle(r.Context(), unstored, now)
+	if len(failed) == len(tasks) {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": failed[tasks[0].TaskID]})
 		return
 	}
 
-	writeJSON(w, http.StatusOK, CreateEventResponse{
-		TaskID:         task.TaskID,
-		IdempotencyKey: task.IdempotencyKey,
-		EntityID:       task.EntityID,
+	resp := CreateEventResponse{
+		EventID:  event.EventID,
+		EntityID: event.EntityID,
+		SendAt:   sendAt,
+
+		QuotaWarning: quota.Warning,
+	}
+	if quota.Warning != "" {
+		w.Header().Set("X-Quota-Warning", quota.Warning)
+	}
+	for _, task := range tasks {
+		resp.Tasks = append(resp.Tasks, CreatedTask{
+			TaskID:         task.TaskID,
+			IdempotencyKey: task.IdempotencyKey,
+			Channel:        task.Channel,
+			Recipient:      task.Recipient,
+			Status:         task.Status,
+			DigestTaskID:   task.DigestTaskID,
+			Error:          failed[task.TaskID],
+		})
+	}
+	// Single-target callers keep reading the top-level fields
+	resp.TaskID = tasks[0].TaskID
+	resp.IdempotencyKey = tasks[0].IdempotencyKey
+
+	code := http.StatusOK
+	if len(failed) > 0 {
+		code = http.StatusMultiStatus // some targets failed; see tasks[].error
+	}
+	writeJSON(w, code, resp)
+}
+
+// createTask stores one fan-out task, hands it to its digest window if it
+// has one, and publishes it if it is due now. A task that stored but could
+// not be published is left SCHEDULED with a past send_at, so the scheduler
+// publishes it on its next poll.
+func (a *App) createTask(ctx context.Context, task *models.Task, d *models.Digest) error {
+	if err := a.Store.PutTask(ctx, *task); err != nil {
+		return errors.New("failed to store task")
+	}
+	// Task first, so the scheduler never closes a window over a missing task
+	if d != nil {
+		if err := a.holdForDigest(ctx, task, d); err != nil {
+			task.Status, task.DigestTaskID = "PENDING", "" // send it on its own
+			if err := a.Store.PutTask(ctx, *task); err != nil {
+				return errors.New("failed to batch task")
+			}
+		}
+	}
+
+	// Scheduled and batched tasks wait in Dynamo
+	if task.Status != "PENDING" {
+		return nil
+	}
+	if err := a.TasksProducer.PublishTask(ctx, task.TaskID, task.Priority); err != nil {
+		ok, terr := a.Store.TransitionStatus(ctx, task.TaskID, []string{"PENDING"}, "SCHEDULED", time.Now().UnixMilli())
+		if terr != nil || !ok {
+			return fmt.Errorf("failed to publish to kafka: %w", err)
+		}
+		task.Status = "SCHEDULED"
+	}
+	return nil
+}
+
+// resolveTargets expands the request into validated (channel, recipient) pairs.
+// Explicit targets win; otherwise recipients (or the single recipient) are
+// paired with channel. Duplicate pairs collapse into one task.
+func (a *App) resolveTargets(req CreateEventRequest) ([]EventTarget, error) {
+	raw := req.Targets
+	if len(raw) == 0 {
+		recipients := req.Recipients
+		if len(recipients) == 0 {
+			single := req.Recipient
+			if single == "" {
+				single = req.RecipientEmail
+			}
+			recipients = []string{single}
+		}
+		for _, rcpt := range recipients {
+			raw = append(raw, EventTarget{Channel: req.Channel, Recipient: rcpt})
+		}
+	}
+	if len(raw) > maxTargetsPerEvent {
+		return nil, fmt.Errorf("at most %d targets per event", maxTargetsPerEvent)
+	}
+
+	seen := map[string]bool{}
+	out := make([]EventTarget, 0, len(raw))
+	for _, t := range raw {
+		t.Channel = strings.ToUpper(t.Channel)
+		if t.Channel == "" {
+			t.Channel = "EMAIL"
+		}
+		if !supportedChannels[t.Channel] {
+			return nil, fmt.Errorf("unsupported channel: %s", t.Channel)
+		}
+		rcpt, err := a.normalizeTaskRecipient(t.Channel, t.Recipient)
+		if err != nil {
+			return nil, err
+		}
+		t.Recipient = rcpt
+
+		key := t.Channel + ":" + t.Recipient
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		chain := t.Fallback
+		if len(chain) == 0 {
+			chain = req.Fallback
+		}
+		if len(chain) == 0 {
+			chain = a.FallbackChains[req.EventType]
+		}
+		if t.steps, err = a.resolveFallback(chain, t.Recipient); err != nil {
+			return nil, err
+		}
+
+		out = append(out, t)
+	}
+	return out, nil
+}
+
+// resolveFallback validates a chain and fixes each step's recipient up front,
+// so the worker only has to copy steps onto new tasks.
+func (a *App) resolveFallback(chain []FallbackStepRequest, recipient string) ([]models.FallbackStep, error) {
+	if len(chain) > maxFallbackSteps {
+		return nil, fmt.Errorf("at most %d fallback steps", maxFallbackSteps)
+	}
+
+	steps := make([]models.FallbackStep, 0, len(chain))
+	for _, s := range chain {
+		ch := strings.ToUpper(s.Channel)
+		if !supportedChannels[ch] {
+			return nil, fmt.Errorf("unsupported fallback channel: %s", s.Channel)
+		}
+		if s.DelaySeconds < 0 {
+			return nil, fmt.Errorf("fallback delaySeconds must be >= 0")
+		}
+		rcpt := s.Recipient
+		if rcpt == "" {
+			rcpt = recipient
+		}
+		rcpt, err := a.normalizeTaskRecipient(ch, rcpt)
+		if err != nil {
+			return nil, fmt.Errorf("fallback %s: %w", ch, err)
+		}
+		steps = append(steps, models.FallbackStep{Channel: ch, Recipient: rcpt, DelaySeconds: s.DelaySeconds})
+	}
+	return steps, nil
+}
+
+func (a *App) getEventHandler(w http.ResponseWriter, r *http.Request) {
+	eventID := chi.URLParam(r, "event_id")
+
+	event, err := a.Store.GetEvent(r.Context(), eventID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load event"})
+		return
+	}
+	if event == nil || !models.OwnedBy(event.TenantID, tenantOf(r)) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "event not found"})
+		return
+	}
+
+	tasks, err := a.Store.GetTasksByIDs(r.Context(), event.TaskIDs)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load tasks"})
+		return
+	}
+
+	status, counts := models.AggregateStatus(tasks)
+	writeJSON(w, http.StatusOK, map[string]any{
+		"event":         event,
+		"status":        status,
+		"status_counts": counts,
+		"tasks":         tasks,
 	})
 }
+
 func (a *App) ReplayTaskHandler(w http.ResponseWriter, r *http.Request) {
 	taskID := chi.URLParam(r, "task_id")
 	if taskID == "" {
@@ -149,22 +568,132 @@ func (a *App) ReplayTaskHandler(w http.ResponseWriter, r *http.Request) {
 
 	nowMs := time.Now().UnixMilli()
 
-	// 1) Reset Dynamo record
+	// 1) Only the owning tenant may replay
+	task, err := a.Store.GetTaskByID(r.Context(), taskID)
+	if err != nil {
+		http.Error(w, "failed to load task", http.StatusInternalServerError)
+		return
+	}
+	if task == nil || !models.OwnedBy(task.TenantID, tenantOf(r)) {
+		http.Error(w, "task not found", http.StatusNotFound)
+		return
+	}
+
+	// 2) Reset Dynamo record
 	if err := a.Store.ResetForReplay(r.Context(), taskID, nowMs); err != nil {
 		http.Error(w, "failed to reset task: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	// 2) Publish to Kafka main topic so worker picks it up
-	if err := a.TasksProducer.PublishTask(r.Context(), taskID); err != nil {
+	// 3) Publish to the task's priority lane so worker picks it up
+	if err := a.TasksProducer.PublishTask(r.Context(), taskID, task.Priority); err != nil {
 		http.Error(w, "failed to publish to kafka: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	// 3) Return something useful to UI
+	after, err := a.Store.GetTaskByID(r.Context(), taskID)
+	if err != nil {
+		after = nil // audited without the after state
+	}
+	a.audit(r, models.AuditTaskReplay, "task", taskID, task, after)
+
+	// 4) Return something useful to UI
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(map[string]any{
 		"ok":      true,
 		"task_id": taskID,
 	})
 }
+
+// cancellableStatuses are the states a task can be cancelled from. A
+// PROCESSING task is already in a worker's hands and finishes normally.
+var cancellableStatuses = []string{"PENDING", "FAILED", "SCHEDULED", "BATCHED"}
+
+type CancelTasksRequest struct {
+	EntityID  string `json:"entityId"`
+	EventType string `json:"eventType"` // optional: only this event type
+}
+
+// cancelTaskHandler stops a queued, retrying or scheduled task. Kafka
+// messages already in flight for it are dropped by the worker's claim.
+func (a *App) cancelTaskHandler(w http.ResponseWriter, r *http.Request) {
+	taskID := chi.URLParam(r, "task_id")
+
+	task, err := a.Store.GetTaskByID(r.Context(), taskID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load task"})
+		return
+	}
+	if task == nil || !models.OwnedBy(task.TenantID, tenantOf(r)) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "task not found"})
+		return
+	}
+
+	now := time.Now().UnixMilli()
+	ok, err := a.Store.TransitionStatus(r.Context(), taskID, cancellableStatuses, "CANCELLED", now)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to cancel task"})
+		return
+	}
+	if !ok {
+		// Moved on since we loaded it; report where it is now
+		if task, err = a.Store.GetTaskByID(r.Context(), taskID); err != nil || task == nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load task"})
+			return
+		}
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "task is " + task.Status + " and can no longer be cancelled"})
+		return
+	}
+	a.auditCancel(r, *task, now)
+	writeJSON(w, http.StatusOK, map[string]any{"ok": true, "task_id": taskID, "status": "CANCELLED"})
+}
+
+func (a *App) auditCancel(r *http.Request, before models.Task, nowMs int64) {
+	after := before
+	after.Status, after.UpdatedAt = "CANCELLED", nowMs
+	a.audit(r, models.AuditTaskCancel, "task", before.TaskID, before, after)
+}
+
+// cancelTasksHandler cancels every cancellable task of an entity, e.g. when
+// the ticket was resolved. Tasks that move on concurrently are reported as skipped.
+func (a *App) cancelTasksHandler(w http.ResponseWriter, r *http.Request) {
+	var req CancelTasksRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON"})
+		return
+	}
+	if req.EntityID == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "entityId required"})
+		return
+	}
+
+	tasks, err := a.Store.FetchTasksByEntity(r.Context(), tenantOf(r), req.EntityID, cancellableStatuses)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load tasks"})
+		return
+	}
+
+	cancelled, skipped := []string{}, []string{}
+	for _, t := range tasks {
+		if req.EventType != "" && t.EventType != req.EventType {
+			continue
+		}
+		now := time.Now().UnixMilli()
+		ok, err := a.Store.TransitionStatus(r.Context(), t.TaskID, cancellableStatuses, "CANCELLED", now)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to cancel task " + t.TaskID})
+			return
+		}
+		if ok {
+			a.auditCancel(r, t, now)
+			cancelled = append(cancelled, t.TaskID)
+		} else {
+			skipped = append(skipped, t.TaskID)
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"entity_id": req.EntityID,
+		"cancelled": cancelled,
+		"skipped":   skipped,
+	})
+}
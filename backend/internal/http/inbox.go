@@ -0,0 +1,96 @@
+package httpapi
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"safe-notify/internal/models"
+	"safe-notify/internal/store"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func (a *App) listInboxHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	view := r.URL.Query().Get("view")
+	switch view {
+	case "", "all", "unread", "archived":
+	default:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "view must be all, unread or archived"})
+		return
+	}
+
+	limit := int32(20)
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 100 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "limit must be 1-100"})
+			return
+		}
+		limit = int32(n)
+	}
+
+	items, next, err := a.Store.ListInbox(r.Context(), tenantOf(r), userID, view, limit, r.URL.Query().Get("cursor"))
+	if errors.Is(err, store.ErrInvalidCursor) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid cursor"})
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load inbox"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": items, "next_cursor": next})
+}
+
+func (a *App) unreadCountHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDParam(w, r)
+	if !ok {
+		return
+	}
+	n, err := a.Store.CountUnread(r.Context(), tenantOf(r), userID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to count unread"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]int{"unread": n})
+}
+
+// inboxUpdateHandler returns a handler that sets the given flags on one item.
+func (a *App) inboxUpdateHandler(read, archived *bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := userIDParam(w, r)
+		if !ok {
+			return
+		}
+		itemID := chi.URLParam(r, "item_id")
+
+		now := time.Now().UnixMilli()
+		before, err := a.Store.UpdateInboxItem(r.Context(), tenantOf(r), userID, itemID, read, archived, now)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to update inbox item"})
+			return
+		}
+		if before == nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "inbox item not found"})
+			return
+		}
+		after := *before
+		if read != nil {
+			after.Read, after.ReadAt = *read, 0
+			if *read {
+				after.ReadAt = now
+			}
+		}
+		if archived != nil {
+			after.Archived = *archived
+		}
+		a.audit(r, models.AuditInboxUpdate, "inbox_item", itemID, before, after)
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true, "item_id": itemID})
+	}
+}
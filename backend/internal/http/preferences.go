@@ -0,0 +1,165 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"safe-notify/internal/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type PutPreferenceRequest struct {
+	PreferredChannel string          `json:"preferredChannel"`
+	Locale           string          `json:"locale"`
+	Subscriptions    map[string]bool `json:"subscriptions"` // "<eventType>:<channel>" -> opted in
+	Timezone         string          `json:"timezone"`      // IANA, used for quietHours
+	QuietHours       *struct {
+		Start string `json:"start"` // "22:00"
+		End   string `json:"end"`   // "07:00"
+	} `json:"quietHours"`
+}
+
+// localeRe accepts BCP 47-style tags; the locale becomes part of a
+// template file name.
+var localeRe = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+func normalizeRecipient(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
+
+func (a *App) getPreferenceHandler(w http.ResponseWriter, r *http.Request) {
+	recipient := normalizeRecipient(chi.URLParam(r, "recipient"))
+	if recipient == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "recipient required"})
+		return
+	}
+	if !validRecordID(recipient) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "recipient may only contain # as its first character"})
+		return
+	}
+
+	p, err := a.Store.GetPreference(r.Context(), tenantOf(r), recipient)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load preferences"})
+		return
+	}
+	if p == nil {
+		// No record means defaults: everything allowed
+		p = &models.Preference{Recipient: recipient, TenantID: tenantOf(r), Subscriptions: map[string]bool{}}
+	}
+	writeJSON(w, http.StatusOK, p)
+}
+
+func (a *App) putPreferenceHandler(w http.ResponseWriter, r *http.Request) {
+	recipient := normalizeRecipient(chi.URLParam(r, "recipient"))
+	if recipient == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "recipient required"})
+		return
+	}
+	if !validRecordID(recipient) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "recipient may only contain # as its first character"})
+		return
+	}
+
+	var req PutPreferenceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON"})
+		return
+	}
+
+	subs := map[string]bool{}
+	for k, v := range req.Subscriptions {
+		evt, ch, ok := strings.Cut(k, ":")
+		if !ok {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "subscription keys must be <eventType>:<channel>"})
+			return
+		}
+		subs[models.SubscriptionKey(evt, strings.ToUpper(ch))] = v
+	}
+
+	req.PreferredChannel = strings.ToUpper(req.PreferredChannel)
+	if req.PreferredChannel != "" && !supportedChannels[req.PreferredChannel] {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported preferredChannel: " + req.PreferredChannel})
+		return
+	}
+	if req.Locale != "" && !localeRe.MatchString(req.Locale) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "locale must be a language tag like en or pt-BR"})
+		return
+	}
+	if req.Timezone != "" {
+		if _, err := time.LoadLocation(req.Timezone); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unknown timezone: " + req.Timezone})
+			return
+		}
+	}
+	var quiet *models.QuietHours
+	if req.QuietHours != nil {
+		quiet = &models.QuietHours{Start: req.QuietHours.Start, End: req.QuietHours.End}
+		for _, c := range []string{quiet.Start, quiet.End} {
+			if _, err := models.ParseClock(c); err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "quietHours: " + err.Error()})
+				return
+			}
+		}
+	}
+
+	p := models.Preference{
+		Recipient:        recipient,
+		TenantID:         tenantOf(r),
+		PreferredChannel: req.PreferredChannel,
+		Locale:           req.Locale,
+		Subscriptions:    subs,
+		Timezone:         req.Timezone,
+		QuietHours:       quiet,
+		UpdatedAt:        time.Now().UnixMilli(),
+	}
+
+	before, err := a.Store.GetPreference(r.Context(), p.TenantID, recipient)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load preferences"})
+		return
+	}
+	if err := a.Store.PutPreference(r.Context(), p); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to store preferences"})
+		return
+	}
+	a.audit(r, models.AuditPreferenceUpdate, "preference", recipient, before, p)
+	writeJSON(w, http.StatusOK, p)
+}
+
+// preferredChannels returns, for each recipient targeted on more than one
+// channel, the channel their preferences name if it is one of those.
+// Recipients are keyed as normalizeRecipient leaves them.
+func (a *App) preferredChannels(ctx context.Context, tenantID string, targets []EventTarget) (map[string]string, error) {
+	channels := map[string]map[string]bool{}
+	for _, t := range targets {
+		rcpt := normalizeRecipient(t.Recipient)
+		if rcpt == "" {
+			continue
+		}
+		if channels[rcpt] == nil {
+			channels[rcpt] = map[string]bool{}
+		}
+		channels[rcpt][t.Channel] = true
+	}
+
+	preferred := map[string]string{}
+	for rcpt, chs := range channels {
+		if len(chs) < 2 {
+			continue
+		}
+		p, err := a.Store.GetPreference(ctx, tenantID, rcpt)
+		if err != nil {
+			return nil, err
+		}
+		if p != nil && chs[p.PreferredChannel] {
+			preferred[rcpt] = p.PreferredChannel
+		}
+	}
+	return preferred, nil
+}
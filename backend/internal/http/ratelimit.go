@@ -0,0 +1,97 @@
+package httpapi
+
+import (
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"safe-notify/internal/ratelimit"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// routeOf is the route a request matched, as configured in
+// RequestLimits ("POST /events"). Only valid once chi has routed the
+// request, i.e. in group middleware and handlers.
+func routeOf(r *http.Request) string {
+	return r.Method + " " + chi.RouteContext(r.Context()).RoutePattern()
+}
+
+// clientIP is the caller's address. Behind a proxy (TrustForwardedFor) it
+// is the last X-Forwarded-For entry, the one the proxy itself appended.
+func (a *App) clientIP(r *http.Request) string {
+	if a.TrustForwardedFor {
+		if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+			parts := strings.Split(xff, ",")
+			return strings.TrimSpace(parts[len(parts)-1])
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// limitByIP applies the route's per-IP limit. It runs before requireScope,
+// so unauthenticated floods are turned away before any key lookup.
+func (a *App) limitByIP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := routeOf(r)
+		l := a.RequestLimits.For(route)
+		if a.limitRequest(w, r, route, l.IP, "ip:"+a.clientIP(r)) {
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// limitByKey applies the route's per-key and per-tenant limits. It runs
+// after requireScope, which has identified the caller.
+func (a *App) limitByKey(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := routeOf(r)
+		l := a.RequestLimits.For(route)
+		keyID := "anonymous"
+		if k := APIKeyFromContext(r.Context()); k != nil {
+			keyID = k.KeyID
+		}
+		if a.limitRequest(w, r, route, l.Key, "key:"+keyID) &&
+			a.limitRequest(w, r, route, l.Tenant, "tenant:"+tenantOf(r)) {
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// limitRequest counts r against one window and sets the RateLimit-*
+// headers. It writes a 429 and returns false if the window is full. A
+// later, tighter window overwrites the headers of an earlier one. If the
+// counters are unavailable the request is let through.
+func (a *App) limitRequest(w http.ResponseWriter, r *http.Request, route string, win *ratelimit.Window, who string) bool {
+	if win == nil || a.RequestCounters == nil {
+		return true
+	}
+	res, err := win.Hit(r.Context(), a.RequestCounters, route+"|"+who, time.Now())
+	if err != nil {
+		log.Println("api: rate limit check failed:", route, err)
+		return true
+	}
+
+	h := w.Header()
+	if prev, err := strconv.Atoi(h.Get("RateLimit-Remaining")); err != nil || res.Remaining <= prev {
+		reset := int64(math.Ceil(res.Reset.Seconds()))
+		h.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+		h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		h.Set("RateLimit-Reset", strconv.FormatInt(reset, 10))
+		h.Set("RateLimit-Policy", strconv.Itoa(win.Limit)+";w="+strconv.Itoa(win.WindowSeconds))
+	}
+	if res.Allowed {
+		return true
+	}
+	h.Set("Retry-After", h.Get("RateLimit-Reset"))
+	writeJSON(w, http.StatusTooManyRequests, map[string]string{"error": "rate limit exceeded for " + strings.SplitN(who, ":", 2)[0]})
+	return false
+}
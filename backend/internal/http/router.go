@@ -1,10 +1,88 @@
 package httpapi
 
-import "github.com/go-chi/chi/v5"
+import (
+	"safe-notify/internal/models"
+
+	"github.com/go-chi/chi/v5"
+)
 
 func RegisterRoutes(r chi.Router, app *App) {
+	yes, no := true, false
+
+	// Unauthenticated: health checks, SNS callbacks (checked against SNSToken,
+	// so only registered when one is set) and unsubscribe links (checked by
+	// their signed token).
+	// Every route but /healthz is rate limited (RequestLimits): per IP
+	// before authentication, per key and tenant after it.
 	r.Get("/healthz", healthHandler)
-	r.Get("/notifications", app.listNotificationsHandler)
-	r.Post("/events", app.createEvent)
-	r.Post("/tasks/{task_id}/replay", app.ReplayTaskHandler)
+	r.Group(func(r chi.Router) {
+		r.Use(app.limitByIP)
+
+		if app.SNSToken != "" {
+			r.Post("/ses/notifications", app.sesNotificationHandler)
+		}
+		if app.Unsubscribe != nil {
+			r.Get("/unsubscribe", app.unsubscribeFormHandler)
+			r.Post("/unsubscribe", app.unsubscribeHandler)
+		}
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(app.limitByIP, app.requireScope(models.ScopeTasksRead), app.limitByKey)
+
+		r.Get("/notifications", app.listNotificationsHandler)
+		r.Get("/events/{event_id}", app.getEventHandler)
+		r.Get("/schedules", app.listSchedulesHandler)
+		r.Get("/schedules/{schedule_id}", app.getScheduleHandler)
+		r.Get("/preferences/{recipient}", app.getPreferenceHandler)
+		r.Get("/users/{user_id}/devices", app.listDevicesHandler)
+		r.Get("/users/{user_id}/inbox", app.listInboxHandler)
+		r.Get("/users/{user_id}/inbox/unread_count", app.unreadCountHandler)
+		r.Get("/usage", app.usageHandler)
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(app.limitByIP, app.requireScope(models.ScopeEventsWrite), app.limitByKey)
+
+		r.Post("/events", app.createEvent)
+		r.Post("/tasks/cancel", app.cancelTasksHandler)
+		r.Post("/tasks/{task_id}/cancel", app.cancelTaskHandler)
+
+		r.Post("/schedules", app.createScheduleHandler)
+		r.Delete("/schedules/{schedule_id}", app.deleteScheduleHandler)
+		r.Post("/schedules/{schedule_id}/pause", app.pauseScheduleHandler(true))
+		r.Post("/schedules/{schedule_id}/resume", app.pauseScheduleHandler(false))
+
+		r.Put("/preferences/{recipient}", app.putPreferenceHandler)
+
+		r.Post("/users/{user_id}/devices", app.registerDeviceHandler)
+		r.Delete("/users/{user_id}/devices/{token}", app.deregisterDeviceHandler)
+
+		r.Post("/users/{user_id}/inbox/{item_id}/read", app.inboxUpdateHandler(&yes, nil))
+		r.Post("/users/{user_id}/inbox/{item_id}/unread", app.inboxUpdateHandler(&no, nil))
+		r.Post("/users/{user_id}/inbox/{item_id}/archive", app.inboxUpdateHandler(nil, &yes))
+		r.Post("/users/{user_id}/inbox/{item_id}/unarchive", app.inboxUpdateHandler(nil, &no))
+	})
+
+	r.With(app.limitByIP, app.requireScope(models.ScopeTasksReplay), app.limitByKey).Post("/tasks/{task_id}/replay", app.ReplayTaskHandler)
+
+	r.Group(func(r chi.Router) {
+		r.Use(app.limitByIP, app.requireScope(models.ScopeAdmin), app.limitByKey)
+
+		// The suppression list protects the shared provider reputation, so
+		// it spans tenants and only the bootstrap key manages it.
+		r.With(app.requireBootstrap).Get("/admin/suppressions", app.listSuppressionsHandler)
+		r.With(app.requireBootstrap).Post("/admin/suppressions", app.addSuppressionHandler)
+		r.With(app.requireBootstrap).Delete("/admin/suppressions/{address}", app.deleteSuppressionHandler)
+
+		r.Get("/admin/api-keys", app.listAPIKeysHandler)
+		r.Post("/admin/api-keys", app.createAPIKeyHandler)
+		r.Delete("/admin/api-keys/{key_id}", app.deleteAPIKeyHandler)
+
+		r.Get("/admin/audit", app.listAuditHandler)
+
+		r.Get("/webhooks", app.listWebhooksHandler)
+		r.Post("/webhooks", app.createWebhookHandler)
+		r.Delete("/webhooks/{subscription_id}", app.deleteWebhookHandler)
+	})
 }
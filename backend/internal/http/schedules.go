@@ -0,0 +1,203 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"safe-notify/internal/cron"
+	"safe-notify/internal/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// CreateScheduleRequest fires Payload (same shape as POST /events, minus
+// sendAt) at every occurrence of Cron, evaluated in Timezone.
+type CreateScheduleRequest struct {
+	Cron     string             `json:"cron"`     // "0 9 * * MON-FRI", "@daily", ...
+	Timezone string             `json:"timezone"` // IANA, default UTC; also used for local startAt/endAt
+	StartAt  string             `json:"startAt"`  // optional, RFC 3339 or local time
+	EndAt    string             `json:"endAt"`    // optional, RFC 3339 or local time
+	Payload  CreateEventRequest `json:"payload"`
+}
+
+func (a *App) listSchedulesHandler(w http.ResponseWriter, r *http.Request) {
+	items, err := a.Store.ListSchedules(r.Context(), tenantOf(r))
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load schedules"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": items})
+}
+
+// loadSchedule writes the error response and returns nil unless the
+// schedule exists and belongs to the caller's tenant.
+func (a *App) loadSchedule(w http.ResponseWriter, r *http.Request) *models.Schedule {
+	sch, err := a.Store.GetSchedule(r.Context(), chi.URLParam(r, "schedule_id"))
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load schedule"})
+		return nil
+	}
+	if sch == nil || !models.OwnedBy(sch.TenantID, tenantOf(r)) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "schedule not found"})
+		return nil
+	}
+	return sch
+}
+
+func (a *App) getScheduleHandler(w http.ResponseWriter, r *http.Request) {
+	if sch := a.loadSchedule(w, r); sch != nil {
+		writeJSON(w, http.StatusOK, sch)
+	}
+}
+
+func (a *App) createScheduleHandler(w http.ResponseWriter, r *http.Request) {
+	var req CreateScheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON"})
+		return
+	}
+	if _, err := cron.Parse(req.Cron); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if req.Timezone == "" {
+		req.Timezone = "UTC"
+	}
+	if req.Payload.SendAt != "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "payload.sendAt is not allowed; use cron and startAt"})
+		return
+	}
+
+	startAt, _, err := parseSendAt(req.StartAt, req.Timezone)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "startAt: " + err.Error()})
+		return
+	}
+	endAt, _, err := parseSendAt(req.EndAt, req.Timezone)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "endAt: " + err.Error()})
+		return
+	}
+	if endAt > 0 && endAt <= startAt {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "endAt must be after startAt"})
+		return
+	}
+
+	p := req.Payload
+	if p.EventType == "" {
+		p.EventType = "ticket_escalated"
+	}
+	if p.EntityID == "" {
+		p.EntityID = "TICKET-XXXX"
+	}
+	p.Priority = strings.ToUpper(p.Priority)
+	if p.Priority == "" {
+		p.Priority = "HIGH"
+	}
+	if !models.ValidPriority(p.Priority) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "priority must be one of " + strings.Join(models.Priorities, ", ")})
+		return
+	}
+	if p.CallbackURL != "" {
+		if err := a.checkWebhookURL(p.CallbackURL); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "callbackUrl " + err.Error()})
+			return
+		}
+	}
+	targets, err := a.resolveTargets(p)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	now := time.Now().UnixMilli()
+	next, err := cron.NextRun(req.Cron, req.Timezone, now, startAt, endAt)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if next == 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "cron expression has no occurrence before endAt"})
+		return
+	}
+
+	sch := models.Schedule{
+		ScheduleID:  "sch_" + randomHex(8),
+		TenantID:    tenantOf(r),
+		Cron:        strings.TrimSpace(req.Cron),
+		Timezone:    req.Timezone,
+		EventType:   p.EventType,
+		EntityID:    p.EntityID,
+		Priority:    p.Priority,
+		CallbackURL: p.CallbackURL,
+		StartAt:     startAt,
+		EndAt:       endAt,
+		NextRunAt:   next,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	for _, t := range targets {
+		sch.Targets = append(sch.Targets, models.ScheduleTarget{Channel: t.Channel, Recipient: t.Recipient, Fallback: t.steps})
+	}
+
+	if err := a.Store.PutSchedule(r.Context(), sch); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to store schedule"})
+		return
+	}
+	a.audit(r, models.AuditScheduleCreate, "schedule", sch.ScheduleID, nil, sch)
+	writeJSON(w, http.StatusOK, sch)
+}
+
+func (a *App) deleteScheduleHandler(w http.ResponseWriter, r *http.Request) {
+	sch := a.loadSchedule(w, r)
+	if sch == nil {
+		return
+	}
+	if err := a.Store.DeleteSchedule(r.Context(), sch.ScheduleID); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to delete schedule"})
+		return
+	}
+	a.audit(r, models.AuditScheduleDelete, "schedule", sch.ScheduleID, sch, nil)
+	writeJSON(w, http.StatusOK, map[string]bool{"ok": true})
+}
+
+// pauseScheduleHandler pauses or resumes a schedule. Resuming picks up at the
+// next occurrence after now; occurrences missed while paused are not sent.
+func (a *App) pauseScheduleHandler(paused bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sch := a.loadSchedule(w, r)
+		if sch == nil {
+			return
+		}
+
+		now := time.Now().UnixMilli()
+		next := sch.NextRunAt
+		var err error
+		if !paused {
+			if next, err = cron.NextRun(sch.Cron, sch.Timezone, now, sch.StartAt, sch.EndAt); err != nil {
+				writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+				return
+			}
+		}
+
+		ok, err := a.Store.SetSchedulePaused(r.Context(), sch.ScheduleID, paused, next, now)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to update schedule"})
+			return
+		}
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "schedule not found"})
+			return
+		}
+		before := *sch
+		sch.Paused, sch.NextRunAt, sch.UpdatedAt = paused, next, now
+		action := models.AuditScheduleResume
+		if paused {
+			action = models.AuditSchedulePause
+		}
+		a.audit(r, action, "schedule", sch.ScheduleID, before, sch)
+		writeJSON(w, http.StatusOK, sch)
+	}
+}
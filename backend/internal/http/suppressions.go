@@ -0,0 +1,211 @@
+package httpapi
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"safe-notify/internal/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// snsEnvelope is the outer JSON SNS posts to HTTP subscribers.
+type snsEnvelope struct {
+	Type         string `json:"Type"`
+	MessageID    string `json:"MessageId"`
+	Message      string `json:"Message"`
+	SubscribeURL string `json:"SubscribeURL"`
+}
+
+// sesNotification is the SES event carried inside snsEnvelope.Message.
+type sesNotification struct {
+	NotificationType string `json:"notificationType"`
+	Bounce           struct {
+		BounceType        string `json:"bounceType"`
+		BounceSubType     string `json:"bounceSubType"`
+		BouncedRecipients []struct {
+			EmailAddress   string `json:"emailAddress"`
+			DiagnosticCode string `json:"diagnosticCode"`
+		} `json:"bouncedRecipients"`
+	} `json:"bounce"`
+	Complaint struct {
+		ComplaintFeedbackType string `json:"complaintFeedbackType"`
+		ComplainedRecipients  []struct {
+			EmailAddress string `json:"emailAddress"`
+		} `json:"complainedRecipients"`
+	} `json:"complaint"`
+}
+
+type AddSuppressionRequest struct {
+	Address        string `json:"address"`
+	Reason         string `json:"reason"`
+	Detail         string `json:"detail"`
+	ExpiresInHours int    `json:"expiresInHours"` // 0 = never expires
+}
+
+func (a *App) sesNotificationHandler(w http.ResponseWriter, r *http.Request) {
+	if a.SNSToken == "" || subtle.ConstantTimeCompare([]byte(r.URL.Query().Get("token")), []byte(a.SNSToken)) != 1 {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid token"})
+		return
+	}
+
+	var env snsEnvelope
+	if err := json.NewDecoder(r.Body).Decode(&env); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON"})
+		return
+	}
+
+	switch env.Type {
+	case "SubscriptionConfirmation":
+		confirmSNSSubscription(env.SubscribeURL)
+		writeJSON(w, http.StatusOK, map[string]bool{"ok": true})
+		return
+	case "Notification":
+	default:
+		writeJSON(w, http.StatusOK, map[string]bool{"ok": true})
+		return
+	}
+
+	var n sesNotification
+	if err := json.Unmarshal([]byte(env.Message), &n); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid SES message"})
+		return
+	}
+
+	now := time.Now().UnixMilli()
+	var sups []models.Suppression
+
+	switch n.NotificationType {
+	case "Bounce":
+		// Transient bounces (mailbox full etc.) are left to normal retries
+		if n.Bounce.BounceType != "Permanent" {
+			break
+		}
+		for _, rcpt := range n.Bounce.BouncedRecipients {
+			sups = append(sups, models.Suppression{
+				Address:   normalizeRecipient(rcpt.EmailAddress),
+				Reason:    "BOUNCE",
+				Detail:    strings.TrimSpace(n.Bounce.BounceSubType + " " + rcpt.DiagnosticCode),
+				CreatedAt: now,
+			})
+		}
+	case "Complaint":
+		for _, rcpt := range n.Complaint.ComplainedRecipients {
+			sups = append(sups, models.Suppression{
+				Address:   normalizeRecipient(rcpt.EmailAddress),
+				Reason:    "COMPLAINT",
+				Detail:    n.Complaint.ComplaintFeedbackType,
+				CreatedAt: now,
+			})
+		}
+	}
+
+	for _, sup := range sups {
+		if sup.Address == "" {
+			continue
+		}
+		// 5xx makes SNS redeliver the notification
+		before, err := a.Store.GetSuppression(r.Context(), sup.Address)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load suppression"})
+			return
+		}
+		if err := a.Store.PutSuppression(r.Context(), sup); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to store suppression"})
+			return
+		}
+		// Suppressions aren't tenant-scoped; record them under the default tenant
+		a.auditSystem(r, "", models.AuditActorSES, models.AuditSuppressionAdd, "suppression", sup.Address, before, sup)
+		log.Println("api: suppressed", sup.Address, "reason=", sup.Reason)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"ok": true, "suppressed": len(sups)})
+}
+
+func confirmSNSSubscription(subscribeURL string) {
+	u, err := url.Parse(subscribeURL)
+	if err != nil || u.Scheme != "https" || !strings.HasSuffix(u.Hostname(), ".amazonaws.com") {
+		log.Println("api: refusing SNS SubscribeURL:", subscribeURL)
+		return
+	}
+	resp, err := http.Get(u.String())
+	if err != nil {
+		log.Println("api: SNS subscription confirm failed:", err)
+		return
+	}
+	resp.Body.Close()
+}
+
+func (a *App) listSuppressionsHandler(w http.ResponseWriter, r *http.Request) {
+	sups, err := a.Store.ListSuppressions(r.Context(), 100)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load suppressions"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": sups})
+}
+
+func (a *App) addSuppressionHandler(w http.ResponseWriter, r *http.Request) {
+	var req AddSuppressionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON"})
+		return
+	}
+
+	addr := normalizeRecipient(req.Address)
+	if addr == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "address required"})
+		return
+	}
+	if req.Reason == "" {
+		req.Reason = "MANUAL"
+	}
+
+	now := time.Now().UnixMilli()
+	sup := models.Suppression{
+		Address:   addr,
+		Reason:    strings.ToUpper(req.Reason),
+		Detail:    req.Detail,
+		CreatedAt: now,
+	}
+	if req.ExpiresInHours > 0 {
+		sup.ExpiresAt = now + int64(req.ExpiresInHours)*int64(time.Hour/time.Millisecond)
+	}
+
+	before, err := a.Store.GetSuppression(r.Context(), addr)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load suppression"})
+		return
+	}
+	if err := a.Store.PutSuppression(r.Context(), sup); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to store suppression"})
+		return
+	}
+	a.audit(r, models.AuditSuppressionAdd, "suppression", addr, before, sup)
+	writeJSON(w, http.StatusOK, sup)
+}
+
+func (a *App) deleteSuppressionHandler(w http.ResponseWriter, r *http.Request) {
+	addr := normalizeRecipient(chi.URLParam(r, "address"))
+	if addr == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "address required"})
+		return
+	}
+
+	before, err := a.Store.GetSuppression(r.Context(), addr)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load suppression"})
+		return
+	}
+	if err := a.Store.DeleteSuppression(r.Context(), addr); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to delete suppression"})
+		return
+	}
+	a.audit(r, models.AuditSuppressionDelete, "suppression", addr, before, nil)
+	writeJSON(w, http.StatusOK, map[string]any{"ok": true, "address": addr})
+}
@@ -0,0 +1,65 @@
+package httpapi
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"safe-notify/internal/models"
+)
+
+// ThrottleRule caps sends of one event type per recipient: at most Max
+// tasks in any WindowSeconds, counted per entity (Per "entity", the default)
+// or across all entities (Per "recipient").
+type ThrottleRule struct {
+	Max           int    `json:"max"`
+	WindowSeconds int    `json:"windowSeconds"`
+	Per           string `json:"per"`
+}
+
+// throttleKey returns the rule that applies to task and the store key its
+// sends are recorded under, or false if no rule applies.
+func (a *App) throttleKey(task models.Task) (ThrottleRule, string, bool) {
+	rule, ok := a.ThrottleRules[task.EventType]
+	if !ok || rule.Max <= 0 || rule.WindowSeconds <= 0 {
+		return rule, "", false
+	}
+	scope := task.EntityID
+	if rule.Per == "recipient" {
+		scope = "*"
+	}
+	return rule, fmt.Sprintf("%s|%s|%s|%s|%s", models.TenantOrDefault(task.TenantID), task.EventType, scope, task.Channel, task.Recipient), true
+}
+
+// throttled reports whether task exceeds its event type's rule, and
+// otherwise records it as sent at nowMs. The check is atomic in the store,
+// so concurrent createEvent calls can't both slip under the limit. The
+// window slides: it is always the WindowSeconds before nowMs.
+func (a *App) throttled(ctx context.Context, task models.Task, nowMs int64) (bool, string, error) {
+	rule, key, ok := a.throttleKey(task)
+	if !ok {
+		return false, "", nil
+	}
+	acquired, err := a.Store.AcquireThrottle(ctx, key, rule.Max, int64(rule.WindowSeconds)*1000, nowMs)
+	if err != nil || acquired {
+		return false, "", err
+	}
+	return true, fmt.Sprintf("throttled: at most %d %s per %ds", rule.Max, task.EventType, rule.WindowSeconds), nil
+}
+
+// releaseThrottle gives back the sends throttled recorded at nowMs for
+// tasks that were not stored. Tasks that were never going to be sent
+// (THROTTLED, SUPPRESSED) recorded nothing. A failure is logged: the
+// recipient is throttled a little early, never late.
+func (a *App) releaseThrottle(ctx context.Context, tasks []models.Task, nowMs int64) {
+	for _, task := range tasks {
+		if models.IsTerminal(task.Status) {
+			continue
+		}
+		if _, key, ok := a.throttleKey(task); ok {
+			if err := a.Store.ReleaseThrottle(ctx, key, nowMs); err != nil {
+				log.Println("api: release throttle failed:", key, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package httpapi
+
+import (
+	"html/template"
+	"net/http"
+	"time"
+
+	"safe-notify/internal/models"
+)
+
+var unsubscribePage = template.Must(template.New("unsubscribe").Parse(`<!doctype html>
+<html><body>
+{{if .Done}}<p>You will no longer receive {{.EventType}} notifications at {{.Recipient}}.</p>
+{{else}}<form method="post" action="/unsubscribe?token={{.Token}}">
+<p>Stop sending {{.EventType}} notifications to {{.Recipient}}?</p>
+<button type="submit">Unsubscribe</button>
+</form>{{end}}
+</body></html>`))
+
+type unsubscribeView struct {
+	Recipient string
+	EventType string
+	Token     string
+	Done      bool
+}
+
+// unsubscribeFormHandler serves the footer link. It only renders a confirm
+// button so that link scanners prefetching the URL don't opt people out.
+func (a *App) unsubscribeFormHandler(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	_, recipient, eventType, err := a.Unsubscribe.Verify(token)
+	if err != nil {
+		http.Error(w, "invalid unsubscribe link", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_ = unsubscribePage.Execute(w, unsubscribeView{Recipient: recipient, EventType: eventType, Token: token})
+}
+
+// unsubscribeHandler records the opt-out. Mail clients call it directly per
+// RFC 8058 (body "List-Unsubscribe=One-Click"); the confirm form posts here too.
+func (a *App) unsubscribeHandler(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	tenantID, recipient, eventType, err := a.Unsubscribe.Verify(token)
+	if err != nil {
+		http.Error(w, "invalid unsubscribe link", http.StatusBadRequest)
+		return
+	}
+
+	before, err := a.Store.GetPreference(r.Context(), tenantID, recipient)
+	if err != nil {
+		http.Error(w, "failed to record unsubscribe", http.StatusInternalServerError)
+		return
+	}
+	key := models.SubscriptionKey(eventType, "*")
+	now := time.Now().UnixMilli()
+	if err := a.Store.SetSubscription(r.Context(), tenantID, recipient, key, false, now); err != nil {
+		http.Error(w, "failed to record unsubscribe", http.StatusInternalServerError)
+		return
+	}
+	a.auditSystem(r, tenantID, models.AuditActorUnsubscribe, models.AuditUnsubscribe, "preference", recipient, before, unsubscribed(before, tenantID, recipient, key, now))
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_ = unsubscribePage.Execute(w, unsubscribeView{Recipient: recipient, EventType: eventType, Done: true})
+}
+
+// unsubscribed is before (or the defaults, if there was no record) with
+// key opted out, as SetSubscription leaves it.
+func unsubscribed(before *models.Preference, tenantID, recipient, key string, nowMs int64) models.Preference {
+	after := models.Preference{Recipient: recipient, TenantID: models.TenantOrDefault(tenantID)}
+	if before != nil {
+		after = *before
+	}
+	subs := make(map[string]bool, len(after.Subscriptions)+1)
+	for k, v := range after.Subscriptions {
+		subs[k] = v
+	}
+	subs[key] = false
+	after.Subscriptions, after.UpdatedAt = subs, nowMs
+	return after
+}
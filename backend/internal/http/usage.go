@@ -0,0 +1,146 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"safe-notify/internal/models"
+	"safe-notify/internal/tenant"
+)
+
+// Usage reports cover at most this many periods per request.
+const (
+	maxUsageDays   = 366
+	maxUsageMonths = 36
+)
+
+// reserveQuota counts n new tasks against the tenant's daily and monthly
+// quotas; see tenant.Config.ReserveQuota.
+func (a *App) reserveQuota(ctx context.Context, tenantID string, n int, now time.Time) (tenant.QuotaCheck, error) {
+	return a.Tenants.Get(tenantID).ReserveQuota(ctx, a.Store, tenantID, n, now)
+}
+
+// releaseQuota gives back n tasks reserved at now that were not created.
+// A failure is logged: the tenant is over-counted, never under.
+func (a *App) releaseQuota(ctx context.Context, tenantID string, n int, now time.Time) {
+	if err := tenant.ReleaseQuota(ctx, a.Store, tenantID, n, now); err != nil {
+		log.Println("api: release quota failed:", tenantID, n, err)
+	}
+}
+
+func writeQuotaExceeded(w http.ResponseWriter, check tenant.QuotaCheck, now time.Time) {
+	secs := int64(check.ResetAt.Sub(now).Seconds()) + 1
+	w.Header().Set("Retry-After", strconv.FormatInt(secs, 10))
+	writeJSON(w, http.StatusTooManyRequests, map[string]string{
+		"error":    check.Reason,
+		"reset_at": check.ResetAt.Format(time.RFC3339),
+	})
+}
+
+// GET /usage?period=day|month&from=&to=&format=json|csv
+//
+// from and to are inclusive periods (2026-10-01 for days, 2026-10 for
+// months) and default to the current one. The bootstrap key may pass tenant= to
+// report on another tenant.
+func (a *App) usageHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	tenantID := tenantOf(r)
+	if t := q.Get("tenant"); t != "" && t != tenantID {
+		if !isBootstrap(r) {
+			writeJSON(w, http.StatusForbidden, map[string]string{"error": "only the bootstrap key can read other tenants' usage"})
+			return
+		}
+		if !models.ValidTenantID(t) {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid tenant"})
+			return
+		}
+		tenantID = t
+	}
+
+	layout, months, days, limit := models.UsageDayLayout, 0, 1, maxUsageDays
+	switch q.Get("period") {
+	case "", "day":
+	case "month":
+		layout, months, days, limit = models.UsageMonthLayout, 1, 0, maxUsageMonths
+	default:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "period must be day or month"})
+		return
+	}
+
+	format := q.Get("format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "csv" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "format must be json or csv"})
+		return
+	}
+
+	now := time.Now().UTC().Format(layout)
+	from, err := parseUsagePeriod(q.Get("from"), layout, now)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "from: " + err.Error()})
+		return
+	}
+	to, err := parseUsagePeriod(q.Get("to"), layout, now)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "to: " + err.Error()})
+		return
+	}
+	if to.Before(from) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "to must not be before from"})
+		return
+	}
+
+	rows := []models.UsageRow{}
+	for t, n := from, 0; !t.After(to); t, n = t.AddDate(0, months, days), n+1 {
+		if n == limit {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("at most %d periods per report", limit)})
+			return
+		}
+		got, err := a.Store.GetUsage(r.Context(), tenantID, t.Format(layout))
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load usage"})
+			return
+		}
+		rows = append(rows, got...)
+	}
+
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="usage-%s-%s-%s.csv"`,
+			models.TenantOrDefault(tenantID), from.Format(layout), to.Format(layout)))
+		cw := csv.NewWriter(w)
+		_ = cw.Write([]string{"period", "channel", "status", "count"})
+		for _, row := range rows {
+			_ = cw.Write([]string{row.Period, row.Channel, row.Status, strconv.FormatInt(row.Count, 10)})
+		}
+		cw.Flush()
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"tenant_id": models.TenantOrDefault(tenantID),
+		"from":      from.Format(layout),
+		"to":        to.Format(layout),
+		"rows":      rows,
+	})
+}
+
+func parseUsagePeriod(s, layout, def string) (time.Time, error) {
+	if s == "" {
+		s = def
+	}
+	t, err := time.Parse(layout, strings.TrimSpace(s))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected a period like %s", layout)
+	}
+	return t, nil
+}
@@ -0,0 +1,107 @@
+package httpapi
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"safe-notify/internal/models"
+	"safe-notify/internal/webhook"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type CreateWebhookRequest struct {
+	URL        string   `json:"url"`
+	EventTypes []string `json:"eventTypes"` // empty = all
+	Statuses   []string `json:"statuses"`   // SENT, FAILED, DLQ, SUPPRESSED; empty = all
+}
+
+// CreateWebhookResponse is the only time the signing secret is returned.
+type CreateWebhookResponse struct {
+	models.WebhookSubscription
+	Secret string `json:"secret"`
+}
+
+// checkWebhookURL vets a caller-supplied callback or subscription URL. The
+// worker enforces the same rule on the resolved address when it posts.
+func (a *App) checkWebhookURL(s string) error {
+	return webhook.CheckURL(s, a.AllowPrivateWebhooks)
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func (a *App) listWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	subs, err := a.Store.ListWebhookSubscriptions(r.Context(), tenantOf(r))
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load webhooks"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": subs})
+}
+
+func (a *App) createWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	var req CreateWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON"})
+		return
+	}
+	if err := a.checkWebhookURL(req.URL); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "url " + err.Error()})
+		return
+	}
+
+	statuses := make([]string, 0, len(req.Statuses))
+	for _, s := range req.Statuses {
+		statuses = append(statuses, strings.ToUpper(s))
+	}
+
+	sub := models.WebhookSubscription{
+		SubscriptionID: "wh_" + randomHex(8),
+		TenantID:       tenantOf(r),
+		URL:            req.URL,
+		Secret:         randomHex(32),
+		EventTypes:     req.EventTypes,
+		Statuses:       statuses,
+		CreatedAt:      time.Now().UnixMilli(),
+	}
+
+	if err := a.Store.PutWebhookSubscription(r.Context(), sub); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to store webhook"})
+		return
+	}
+	a.audit(r, models.AuditWebhookCreate, "webhook", sub.SubscriptionID, nil, sub)
+	writeJSON(w, http.StatusOK, CreateWebhookResponse{WebhookSubscription: sub, Secret: sub.Secret})
+}
+
+func (a *App) deleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "subscription_id")
+	if id == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "subscription_id required"})
+		return
+	}
+
+	sub, err := a.Store.GetWebhookSubscription(r.Context(), id)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load webhook"})
+		return
+	}
+	if sub == nil || !models.OwnedBy(sub.TenantID, tenantOf(r)) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "webhook not found"})
+		return
+	}
+
+	if err := a.Store.DeleteWebhookSubscription(r.Context(), id); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to delete webhook"})
+		return
+	}
+	a.audit(r, models.AuditWebhookDelete, "webhook", id, sub, nil)
+	writeJSON(w, http.StatusOK, map[string]any{"ok": true, "subscription_id": id})
+}
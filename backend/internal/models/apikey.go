@@ -0,0 +1,65 @@
+package models
+
+// API key scopes. ScopeAdmin grants every scope.
+const (
+	ScopeEventsWrite = "events:write"
+	ScopeTasksRead   = "tasks:read"
+	ScopeTasksReplay = "tasks:replay"
+	ScopeAdmin       = "admin"
+)
+
+var Scopes = []string{ScopeEventsWrite, ScopeTasksRead, ScopeTasksReplay, ScopeAdmin}
+
+// Roles are named bundles of scopes. A key's role and its explicit scopes
+// add up.
+const (
+	RoleViewer   = "viewer"   // read tasks, events, schedules, usage
+	RoleOperator = "operator" // viewer + send, cancel and replay
+	RoleAdmin    = "admin"    // everything, incl. keys, webhooks and the audit log
+)
+
+var RoleScopes = map[string][]string{
+	RoleViewer:   {ScopeTasksRead},
+	RoleOperator: {ScopeTasksRead, ScopeEventsWrite, ScopeTasksReplay},
+	RoleAdmin:    {ScopeAdmin},
+}
+
+// APIKey is a stored key. The secret itself is never stored, only its
+// SHA-256; keys are shown once as "sn_<key_id>_<secret>".
+type APIKey struct {
+	KeyID      string   `dynamodbav:"key_id" json:"key_id"`
+	Name       string   `dynamodbav:"name" json:"name"`
+	TenantID   string   `dynamodbav:"tenant_id" json:"tenant_id"` // every request with this key acts as this tenant
+	SecretHash string   `dynamodbav:"secret_hash" json:"-"`
+	Role       string   `dynamodbav:"role,omitempty" json:"role,omitempty"`
+	Scopes     []string `dynamodbav:"scopes" json:"scopes"`
+	ExpiresAt  int64    `dynamodbav:"expires_at" json:"expires_at"` // epoch ms, 0 = never
+	CreatedAt  int64    `dynamodbav:"created_at" json:"created_at"`
+}
+
+func (k APIKey) Expired(nowMs int64) bool {
+	return k.ExpiresAt > 0 && nowMs >= k.ExpiresAt
+}
+
+func (k APIKey) HasScope(scope string) bool {
+	for _, s := range append(RoleScopes[k.Role], k.Scopes...) {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+func ValidRole(role string) bool {
+	_, ok := RoleScopes[role]
+	return ok
+}
+
+func ValidScope(scope string) bool {
+	for _, s := range Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
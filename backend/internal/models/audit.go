@@ -0,0 +1,59 @@
+package models
+
+import "encoding/json"
+
+// Audited actions.
+const (
+	AuditTaskReplay        = "task.replay"
+	AuditTaskCancel        = "task.cancel"
+	AuditSuppressionAdd    = "suppression.add"
+	AuditSuppressionDelete = "suppression.delete"
+	AuditAPIKeyCreate      = "api_key.create"
+	AuditAPIKeyDelete      = "api_key.delete"
+	AuditWebhookCreate     = "webhook.create"
+	AuditWebhookDelete     = "webhook.delete"
+	AuditScheduleCreate    = "schedule.create"
+	AuditScheduleDelete    = "schedule.delete"
+	AuditSchedulePause     = "schedule.pause"
+	AuditScheduleResume    = "schedule.resume"
+	AuditPreferenceUpdate  = "preference.update"
+	AuditUnsubscribe       = "preference.unsubscribe"
+	AuditDeviceRegister    = "device.register"
+	AuditDeviceDeregister  = "device.deregister"
+	AuditInboxUpdate       = "inbox.update"
+)
+
+// Actors of changes made without an API key.
+const (
+	AuditActorSES         = "ses"         // SES bounce and complaint feedback
+	AuditActorUnsubscribe = "unsubscribe" // a recipient's unsubscribe link
+)
+
+// AuditRecord is one operational change. Records are only ever inserted:
+// the store has no update or delete for them. Before and After are JSON
+// snapshots of the target (null when it didn't exist before or doesn't
+// after); secrets are never part of them.
+type AuditRecord struct {
+	AuditID    string          `dynamodbav:"audit_id" json:"audit_id"`
+	TenantID   string          `dynamodbav:"tenant_id" json:"tenant_id"` // tenant the actor acted as
+	Actor      string          `dynamodbav:"actor" json:"actor"`         // API key ID, "bootstrap" for ADMIN_API_KEY, or an AuditActor*
+	ActorName  string          `dynamodbav:"actor_name" json:"actor_name"`
+	ActorRole  string          `dynamodbav:"actor_role,omitempty" json:"actor_role,omitempty"`
+	Action     string          `dynamodbav:"action" json:"action"`
+	TargetType string          `dynamodbav:"target_type" json:"target_type"` // task, suppression, api_key, ...
+	TargetID   string          `dynamodbav:"target_id" json:"target_id"`
+	Before     json.RawMessage `dynamodbav:"before,omitempty" json:"before"`
+	After      json.RawMessage `dynamodbav:"after,omitempty" json:"after"`
+	Reason     string          `dynamodbav:"reason,omitempty" json:"reason,omitempty"`
+	At         int64           `dynamodbav:"at" json:"at"` // epoch ms
+}
+
+// AuditFilter narrows an audit query. Empty fields match everything.
+type AuditFilter struct {
+	TenantID string // "" = every tenant
+	Actor    string
+	Action   string
+	TargetID string
+	FromMs   int64
+	ToMs     int64 // exclusive; 0 = now
+}
@@ -0,0 +1,11 @@
+package models
+
+// Device is a push token registered for a user. A user can have many.
+type Device struct {
+	UserID    string `dynamodbav:"user_id" json:"user_id"`
+	TenantID  string `dynamodbav:"tenant_id" json:"tenant_id"`
+	Token     string `dynamodbav:"device_token" json:"device_token"`
+	Platform  string `dynamodbav:"platform" json:"platform"` // ANDROID | IOS | WEB
+	CreatedAt int64  `dynamodbav:"created_at" json:"created_at"`
+	UpdatedAt int64  `dynamodbav:"updated_at" json:"updated_at"`
+}
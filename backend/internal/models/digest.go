@@ -0,0 +1,25 @@
+package models
+
+// Digest is one open (or closing) batching window for a (tenant, channel,
+// recipient, event type). Tasks created inside the window are held as BATCHED and, when
+// it closes, carried by a single digest task with ID DigestTaskID.
+type Digest struct {
+	DigestKey    string        `dynamodbav:"digest_key" json:"digest_key"` // tenant|channel|recipient|event_type|window_start
+	DigestTaskID string        `dynamodbav:"digest_task_id" json:"digest_task_id"`
+	TenantID     string        `dynamodbav:"tenant_id" json:"tenant_id"`
+	Channel      string        `dynamodbav:"channel" json:"channel"`
+	Recipient    string        `dynamodbav:"recipient" json:"recipient"`
+	EventType    string        `dynamodbav:"event_type" json:"event_type"`
+	Entries      []DigestEntry `dynamodbav:"entries" json:"entries"`
+
+	ClosesAt     int64 `dynamodbav:"closes_at" json:"closes_at"`
+	ClosedAt     int64 `dynamodbav:"closed_at" json:"closed_at"`         // no more entries once set
+	DispatchedAt int64 `dynamodbav:"dispatched_at" json:"dispatched_at"` // digest task queued
+}
+
+// DigestEntry is one batched task as shown in the rendered digest.
+type DigestEntry struct {
+	TaskID   string `dynamodbav:"task_id" json:"task_id"`
+	EntityID string `dynamodbav:"entity_id" json:"entity_id"`
+	Priority string `dynamodbav:"priority" json:"priority"`
+}
@@ -0,0 +1,81 @@
+package models
+
+// Event is the parent record of one POST /events call. It fans out into one
+// Task per (channel, recipient) target; its status is aggregated from them.
+type Event struct {
+	EventID   string   `dynamodbav:"event_id" json:"event_id"`
+	TenantID  string   `dynamodbav:"tenant_id" json:"tenant_id"`
+	EventType string   `dynamodbav:"event_type" json:"event_type"`
+	EntityID  string   `dynamodbav:"entity_id" json:"entity_id"`
+	Priority  string   `dynamodbav:"priority" json:"priority"`
+	TaskIDs   []string `dynamodbav:"task_ids" json:"task_ids"`
+	CreatedAt int64    `dynamodbav:"created_at" json:"created_at"`
+}
+
+// terminalStatuses never change again without a manual replay.
+var terminalStatuses = map[string]bool{
+	"SENT":       true,
+	"DLQ":        true,
+	"SUPPRESSED": true,
+	"CANCELLED":  true,
+	"THROTTLED":  true,
+}
+
+func IsTerminal(status string) bool {
+	return terminalStatuses[status]
+}
+
+// AggregateStatus summarises child tasks:
+// IN_PROGRESS until every child is terminal, then SENT (all sent),
+// PARTIAL (some sent), CANCELLED / THROTTLED (all of them) or FAILED. A task that escalated to a
+// fallback is judged by the fallback instead.
+func AggregateStatus(tasks []Task) (string, map[string]int) {
+	counts := map[string]int{}
+	for _, t := range tasks {
+		counts[t.Status]++
+	}
+
+	replaced := map[string]bool{}
+	for _, t := range tasks {
+		if t.FallbackOf != "" {
+			replaced[t.FallbackOf] = true
+		}
+	}
+	live := make([]Task, 0, len(tasks))
+	for _, t := range tasks {
+		if !replaced[t.TaskID] {
+			live = append(live, t)
+		}
+	}
+	tasks = live
+
+	sent, cancelled, throttled := 0, 0, 0
+	for _, t := range tasks {
+		switch t.Status {
+		case "SENT":
+			sent++
+		case "CANCELLED":
+			cancelled++
+		case "THROTTLED":
+			throttled++
+		}
+	}
+
+	for _, t := range tasks {
+		if !IsTerminal(t.Status) {
+			return "IN_PROGRESS", counts
+		}
+	}
+	switch {
+	case sent == len(tasks):
+		return "SENT", counts
+	case cancelled == len(tasks):
+		return "CANCELLED", counts
+	case throttled == len(tasks):
+		return "THROTTLED", counts
+	case sent == 0:
+		return "FAILED", counts
+	default:
+		return "PARTIAL", counts
+	}
+}
@@ -0,0 +1,26 @@
+package models
+
+import "fmt"
+
+// InboxItem is one IN_APP notification in a user's inbox.
+// ItemID sorts chronologically within a user (see InboxItemID).
+type InboxItem struct {
+	UserID    string `dynamodbav:"user_id" json:"user_id"`
+	TenantID  string `dynamodbav:"tenant_id" json:"tenant_id"`
+	ItemID    string `dynamodbav:"item_id" json:"item_id"`
+	TaskID    string `dynamodbav:"task_id" json:"task_id"`
+	EventType string `dynamodbav:"event_type" json:"event_type"`
+	EntityID  string `dynamodbav:"entity_id" json:"entity_id"`
+	Priority  string `dynamodbav:"priority" json:"priority"`
+	Title     string `dynamodbav:"title" json:"title"`
+	Body      string `dynamodbav:"body" json:"body"`
+	Read      bool   `dynamodbav:"read" json:"read"`
+	Archived  bool   `dynamodbav:"archived" json:"archived"`
+	CreatedAt int64  `dynamodbav:"created_at" json:"created_at"`
+	ReadAt    int64  `dynamodbav:"read_at" json:"read_at"`
+}
+
+// InboxItemID is derived from the task so a retried write lands on the same item.
+func InboxItemID(task Task) string {
+	return fmt.Sprintf("%013d-%s", task.CreatedAt, task.TaskID)
+}
@@ -0,0 +1,100 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Preference holds per-recipient delivery settings.
+//
+// PreferredChannel picks one channel when an event targets the recipient on
+// several; Locale picks the recipient's templates, e.g. "pt-BR".
+//
+// Subscriptions is keyed by "<event_type>:<channel>" and either side may be "*".
+// A true value is an explicit opt-in, false an opt-out. Anything not listed is allowed.
+type Preference struct {
+	Recipient        string          `dynamodbav:"recipient" json:"recipient"`
+	TenantID         string          `dynamodbav:"tenant_id" json:"tenant_id"`
+	PreferredChannel string          `dynamodbav:"preferred_channel" json:"preferred_channel"`
+	Locale           string          `dynamodbav:"locale" json:"locale"`
+	Subscriptions    map[string]bool `dynamodbav:"subscriptions" json:"subscriptions"`
+	Timezone         string          `dynamodbav:"timezone" json:"timezone"` // IANA, default UTC
+	QuietHours       *QuietHours     `dynamodbav:"quiet_hours" json:"quiet_hours,omitempty"`
+	UpdatedAt        int64           `dynamodbav:"updated_at" json:"updated_at"`
+}
+
+// QuietHours is a daily local-time window ("22:00" to "07:00" wraps midnight)
+// during which non-HIGH notifications are held.
+type QuietHours struct {
+	Start string `dynamodbav:"start" json:"start"`
+	End   string `dynamodbav:"end" json:"end"`
+}
+
+// ParseClock parses "HH:MM" into minutes after midnight.
+func ParseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("time must be HH:MM, got %q", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// QuietUntil reports whether now falls in the recipient's quiet hours and,
+// if so, when they end.
+func (p Preference) QuietUntil(now time.Time) (time.Time, bool) {
+	if p.QuietHours == nil {
+		return time.Time{}, false
+	}
+	start, err1 := ParseClock(p.QuietHours.Start)
+	end, err2 := ParseClock(p.QuietHours.End)
+	if err1 != nil || err2 != nil || start == end {
+		return time.Time{}, false
+	}
+	loc, err := time.LoadLocation(p.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	local := now.In(loc)
+	m := local.Hour()*60 + local.Minute()
+	quiet := start <= m && m < end
+	if start > end { // overnight window
+		quiet = m >= start || m < end
+	}
+	if !quiet {
+		return time.Time{}, false
+	}
+
+	until := time.Date(local.Year(), local.Month(), local.Day(), end/60, end%60, 0, 0, loc)
+	if !until.After(local) {
+		until = time.Date(local.Year(), local.Month(), local.Day()+1, end/60, end%60, 0, 0, loc)
+	}
+	return until, true
+}
+
+// Allows reports whether the recipient wants eventType on channel.
+// The most specific matching rule wins.
+func (p Preference) Allows(eventType, channel string) bool {
+	keys := []string{
+		eventType + ":" + channel,
+		eventType + ":*",
+		"*:" + channel,
+		"*:*",
+	}
+	for _, k := range keys {
+		if v, ok := p.Subscriptions[k]; ok {
+			return v
+		}
+	}
+	return true
+}
+
+func SubscriptionKey(eventType, channel string) string {
+	if eventType == "" {
+		eventType = "*"
+	}
+	if channel == "" {
+		channel = "*"
+	}
+	return eventType + ":" + channel
+}
@@ -0,0 +1,36 @@
+package models
+
+// Schedule is a recurring notification rule. The scheduler turns each cron
+// occurrence into one Event plus a Task per target, with IDs derived from
+// (ScheduleID, occurrence) so a re-run never creates a second copy.
+type Schedule struct {
+	ScheduleID string `dynamodbav:"schedule_id" json:"schedule_id"`
+	TenantID   string `dynamodbav:"tenant_id" json:"tenant_id"`
+	Cron       string `dynamodbav:"cron" json:"cron"`
+	Timezone   string `dynamodbav:"timezone" json:"timezone"` // IANA; cron fields are evaluated in it
+
+	// Payload of every occurrence
+	EventType   string           `dynamodbav:"event_type" json:"event_type"`
+	EntityID    string           `dynamodbav:"entity_id" json:"entity_id"`
+	Priority    string           `dynamodbav:"priority" json:"priority"`
+	CallbackURL string           `dynamodbav:"callback_url" json:"callback_url"`
+	Targets     []ScheduleTarget `dynamodbav:"targets" json:"targets"`
+
+	// Window (epoch ms, 0 = unbounded) and state. NextRunAt is 0 once the
+	// schedule has run past EndAt.
+	StartAt   int64 `dynamodbav:"start_at" json:"start_at"`
+	EndAt     int64 `dynamodbav:"end_at" json:"end_at"`
+	Paused    bool  `dynamodbav:"paused" json:"paused"`
+	NextRunAt int64 `dynamodbav:"next_run_at" json:"next_run_at"`
+	LastRunAt int64 `dynamodbav:"last_run_at" json:"last_run_at"`
+
+	CreatedAt int64 `dynamodbav:"created_at" json:"created_at"`
+	UpdatedAt int64 `dynamodbav:"updated_at" json:"updated_at"`
+}
+
+// ScheduleTarget is an already-normalised (channel, recipient) pair.
+type ScheduleTarget struct {
+	Channel   string         `dynamodbav:"channel" json:"channel"`
+	Recipient string         `dynamodbav:"recipient" json:"recipient"`
+	Fallback  []FallbackStep `dynamodbav:"fallback" json:"fallback,omitempty"`
+}
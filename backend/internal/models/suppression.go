@@ -0,0 +1,14 @@
+package models
+
+// Suppression blocks all delivery to an address until it expires.
+type Suppression struct {
+	Address   string `dynamodbav:"address" json:"address"`
+	Reason    string `dynamodbav:"reason" json:"reason"` // BOUNCE | COMPLAINT | MANUAL
+	Detail    string `dynamodbav:"detail" json:"detail"`
+	CreatedAt int64  `dynamodbav:"created_at" json:"created_at"`
+	ExpiresAt int64  `dynamodbav:"expires_at" json:"expires_at"` // epoch ms, 0 = never
+}
+
+func (s Suppression) Active(nowMs int64) bool {
+	return s.ExpiresAt == 0 || nowMs < s.ExpiresAt
+}
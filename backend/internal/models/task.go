@@ -4,13 +4,32 @@ type Task struct {
 	// Keys
 	TaskID         string `dynamodbav:"task_id" json:"task_id"`
 	IdempotencyKey string `dynamodbav:"idempotency_key" json:"idempotency_key"`
+	EventID        string `dynamodbav:"event_id" json:"event_id"`   // parent Event (fan-out)
+	TenantID       string `dynamodbav:"tenant_id" json:"tenant_id"` // owner, from the API key; empty = DefaultTenant
 
 	// Business
 	EventType      string `dynamodbav:"event_type" json:"event_type"`
 	EntityID       string `dynamodbav:"entity_id" json:"entity_id"`
 	Channel        string `dynamodbav:"channel" json:"channel"`
+	Recipient      string `dynamodbav:"recipient" json:"recipient"` // channel-specific address (email, E.164 phone, Slack channel...)
 	RecipientEmail string `dynamodbav:"recipient_email" json:"recipient_email"`
 	Priority       string `dynamodbav:"priority" json:"priority"`
+	CallbackURL    string `dynamodbav:"callback_url" json:"callback_url"`
+
+	// Scheduled sends: SendAt (epoch ms) is when the scheduler releases a
+	// SCHEDULED task; Timezone is what the caller's sendAt was expressed in.
+	SendAt   int64  `dynamodbav:"send_at" json:"send_at"`
+	Timezone string `dynamodbav:"timezone" json:"timezone"`
+
+	// Fallback chain: steps still to try, in order, if this task ends in DLQ.
+	// FallbackOf links a fallback task to the task it replaced.
+	Fallback   []FallbackStep `dynamodbav:"fallback" json:"fallback,omitempty"`
+	FallbackOf string         `dynamodbav:"fallback_of" json:"fallback_of"`
+
+	// Digests: a BATCHED task links to the digest task that carries it
+	// (DigestTaskID); a digest task lists what it carries (Digest).
+	DigestTaskID string        `dynamodbav:"digest_task_id" json:"digest_task_id"`
+	Digest       []DigestEntry `dynamodbav:"digest" json:"digest,omitempty"`
 
 	// Processing/Status
 	Status       string `dynamodbav:"status" json:"status"`
@@ -18,6 +37,10 @@ type Task struct {
 	MaxAttempts  int    `dynamodbav:"max_attempts" json:"max_attempts"`
 	LastError    string `dynamodbav:"last_error" json:"last_error"`
 
+	// Locale is the recipient's, from their preferences; the worker sets it
+	// for template selection. It is not stored.
+	Locale string `dynamodbav:"-" json:"-"`
+
 	// Demo-only (chaos)
 	ChaosFailPercent int `dynamodbav:"chaos_fail_percent" json:"chaos_fail_percent"`
 
@@ -28,3 +51,33 @@ type Task struct {
 	ProcessingStartedAt int64  `dynamodbav:"processing_started_at" json:"processing_started_at"`
 	NextRetryAt         int64  `dynamodbav:"next_retry_at" json:"next_retry_at"`
 }
+
+// To returns the address this task is delivered to. Tasks created before
+// Recipient existed only have RecipientEmail.
+func (t Task) To() string {
+	if t.Recipient != "" {
+		return t.Recipient
+	}
+	return t.RecipientEmail
+}
+
+// FallbackStep is one escalation hop, e.g. EMAIL -> SMS -> phone-call WEBHOOK.
+// Recipient is already normalised for Channel when the task is created.
+type FallbackStep struct {
+	Channel      string `dynamodbav:"channel" json:"channel"`
+	Recipient    string `dynamodbav:"recipient" json:"recipient"`
+	DelaySeconds int    `dynamodbav:"delay_seconds" json:"delay_seconds"`
+}
+
+// Priorities accepted for Task.Priority, highest first. Each has its own
+// Kafka lane.
+var Priorities = []string{"HIGH", "NORMAL", "LOW"}
+
+func ValidPriority(p string) bool {
+	for _, v := range Priorities {
+		if p == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,26 @@
+package models
+
+import "regexp"
+
+// DefaultTenant owns records written before tenants existed (no tenant_id)
+// and everything done with the bootstrap admin key.
+const DefaultTenant = "default"
+
+var tenantIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+func ValidTenantID(id string) bool {
+	return tenantIDPattern.MatchString(id)
+}
+
+// TenantOrDefault maps a stored tenant_id to the tenant that owns it.
+func TenantOrDefault(id string) string {
+	if id == "" {
+		return DefaultTenant
+	}
+	return id
+}
+
+// OwnedBy reports whether a record tagged recordTenant belongs to tenantID.
+func OwnedBy(recordTenant, tenantID string) bool {
+	return TenantOrDefault(recordTenant) == TenantOrDefault(tenantID)
+}
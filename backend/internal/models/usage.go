@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// Usage periods are UTC calendar days ("2026-10-19") and months ("2026-10").
+const (
+	UsageDayLayout   = "2006-01-02"
+	UsageMonthLayout = "2006-01"
+)
+
+// UsageStatusAccepted counts tasks accepted by POST /events, which is what
+// quotas are measured in. Other statuses count delivery outcomes recorded by
+// the worker (one per attempt for FAILED).
+const UsageStatusAccepted = "ACCEPTED"
+
+// UsageRow is one counter of a usage report. Channel is "*" for ACCEPTED.
+type UsageRow struct {
+	Period  string `json:"period"`
+	Channel string `json:"channel"`
+	Status  string `json:"status"`
+	Count   int64  `json:"count"`
+}
+
+// UsageDay and UsageMonth return the usage periods containing t.
+func UsageDay(t time.Time) string   { return t.UTC().Format(UsageDayLayout) }
+func UsageMonth(t time.Time) string { return t.UTC().Format(UsageMonthLayout) }
+
+// NextUsageDay and NextUsageMonth return when the periods containing t end,
+// i.e. when their quotas reset.
+func NextUsageDay(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d+1, 0, 0, 0, 0, time.UTC)
+}
+
+func NextUsageMonth(t time.Time) time.Time {
+	y, m, _ := t.UTC().Date()
+	return time.Date(y, m+1, 1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,64 @@
+package models
+
+// WebhookSubscription receives status callbacks for matching tasks.
+// Empty filters match everything.
+type WebhookSubscription struct {
+	SubscriptionID string   `dynamodbav:"subscription_id" json:"subscription_id"`
+	TenantID       string   `dynamodbav:"tenant_id" json:"tenant_id"` // only this tenant's tasks
+	URL            string   `dynamodbav:"url" json:"url"`
+	Secret         string   `dynamodbav:"secret" json:"-"`
+	EventTypes     []string `dynamodbav:"event_types" json:"event_types"`
+	Statuses       []string `dynamodbav:"statuses" json:"statuses"`
+	CreatedAt      int64    `dynamodbav:"created_at" json:"created_at"`
+}
+
+func (s WebhookSubscription) Matches(eventType, status string) bool {
+	return matchAny(s.EventTypes, eventType) && matchAny(s.Statuses, status)
+}
+
+func matchAny(list []string, v string) bool {
+	if len(list) == 0 {
+		return true
+	}
+	for _, x := range list {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery is one signed POST of a task status change, retried
+// through the same retry topic/scheduler as tasks.
+type WebhookDelivery struct {
+	DeliveryID     string `dynamodbav:"delivery_id" json:"delivery_id"`
+	TaskID         string `dynamodbav:"task_id" json:"task_id"`
+	SubscriptionID string `dynamodbav:"subscription_id" json:"subscription_id"` // empty for per-event callbackUrl
+	URL            string `dynamodbav:"url" json:"url"`
+	Payload        string `dynamodbav:"payload" json:"payload"`
+
+	Status       string `dynamodbav:"status" json:"status"` // PENDING | PROCESSING | FAILED | DELIVERED | DLQ
+	AttemptCount int    `dynamodbav:"attempt_count" json:"attempt_count"`
+	MaxAttempts  int    `dynamodbav:"max_attempts" json:"max_attempts"`
+	LastError    string `dynamodbav:"last_error" json:"last_error"`
+
+	CreatedAt   int64 `dynamodbav:"created_at" json:"created_at"`
+	UpdatedAt   int64 `dynamodbav:"updated_at" json:"updated_at"`
+	NextRetryAt int64 `dynamodbav:"next_retry_at" json:"next_retry_at"`
+}
+
+// TaskStatusEvent is the JSON body POSTed to webhook receivers.
+type TaskStatusEvent struct {
+	Event          string `json:"event"` // always "task.status_changed"
+	TaskID         string `json:"task_id"`
+	IdempotencyKey string `json:"idempotency_key"`
+	EventType      string `json:"event_type"`
+	EntityID       string `json:"entity_id"`
+	Channel        string `json:"channel"`
+	Recipient      string `json:"recipient"`
+	Status         string `json:"status"`
+	AttemptCount   int    `json:"attempt_count"`
+	LastError      string `json:"last_error,omitempty"`
+	NextRetryAt    int64  `json:"next_retry_at,omitempty"`
+	Timestamp      int64  `json:"timestamp"`
+}
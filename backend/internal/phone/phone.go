@@ -0,0 +1,55 @@
+package phone
+
+import (
+	"errors"
+	"strings"
+)
+
+var ErrInvalid = errors.New("invalid phone number")
+
+// NormalizeE164 turns user input like "(415) 555-0100" or "0044 20 7946 0000"
+// into "+14155550100" / "+442079460000". Numbers without a country prefix get
+// defaultCountryCode (digits only, e.g. "1"); if that is empty they are rejected.
+func NormalizeE164(raw, defaultCountryCode string) (string, error) {
+	s := strings.TrimSpace(raw)
+	if s == "" {
+		return "", ErrInvalid
+	}
+
+	plus := false
+	switch {
+	case strings.HasPrefix(s, "+"):
+		plus = true
+		s = s[1:]
+	case strings.HasPrefix(s, "00"):
+		plus = true
+		s = s[2:]
+	}
+
+	var digits strings.Builder
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == ' ', r == '-', r == '.', r == '(', r == ')':
+			// common separators
+		default:
+			return "", ErrInvalid
+		}
+	}
+
+	d := digits.String()
+	if !plus {
+		if defaultCountryCode == "" {
+			return "", ErrInvalid
+		}
+		d = strings.TrimPrefix(d, "0") // national trunk prefix
+		d = strings.TrimPrefix(defaultCountryCode, "+") + d
+	}
+
+	// E.164: up to 15 digits, country codes never start with 0
+	if len(d) < 8 || len(d) > 15 || d[0] == '0' {
+		return "", ErrInvalid
+	}
+	return "+" + d, nil
+}
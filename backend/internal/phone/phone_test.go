@@ -0,0 +1,43 @@
+package phone
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalizeE164(t *testing.T) {
+	tests := []struct {
+		raw, country string
+		want         string // "" = ErrInvalid
+	}{
+		{"(415) 555-0100", "1", "+14155550100"},
+		{"415.555.0100", "+1", "+14155550100"},
+		{"+1 415 555 0100", "", "+14155550100"},
+		{"+44 20 7946 0000", "", "+442079460000"},
+		{"0044 20 7946 0000", "", "+442079460000"},
+		{"020 7946 0000", "44", "+442079460000"}, // national trunk 0 dropped
+		{"  +14155550100  ", "", "+14155550100"},
+
+		{"4155550100", "", ""}, // no country code and no default
+		{"", "1", ""},
+		{"+1 415 CALL-NOW", "", ""},
+		{"+1/415/555/0100", "", ""},
+		{"+0123456789", "", ""},       // country codes never start with 0
+		{"+1234567", "", ""},          // too short
+		{"+1234567890123456", "", ""}, // 16 digits, over E.164's 15
+		{"+123456789012345", "", "+123456789012345"},
+	}
+
+	for _, tt := range tests {
+		got, err := NormalizeE164(tt.raw, tt.country)
+		if tt.want == "" {
+			if !errors.Is(err, ErrInvalid) {
+				t.Errorf("NormalizeE164(%q, %q) = %q, %v; want ErrInvalid", tt.raw, tt.country, got, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("NormalizeE164(%q, %q) = %q, %v; want %q", tt.raw, tt.country, got, err, tt.want)
+		}
+	}
+}
@@ -89,6 +89,9 @@ func NewConsumer(brokers []string, topic, groupID string) *Consumer {
 
 func (c *Consumer) Close() error { return c.reader.Close() }
 
+// Lag is how many messages the group is behind on this topic.
+func (c *Consumer) Lag() int64 { return c.reader.Stats().Lag }
+
 // ReadTask consumes TaskMessage.
 func (c *Consumer) ReadTask(ctx context.Context) (TaskMessage, func(context.Context) error, error) {
 	m, err := c.reader.FetchMessage(ctx)
@@ -119,6 +119,16 @@ func (p *Producer) PublishRetry(ctx context.Context, taskID string, nextRetryAt
 	return p.publishJSON(ctx, taskID, msg)
 }
 
+func (p *Producer) PublishWebhook(ctx context.Context, deliveryID string) error {
+	msg := TaskMessage{TaskID: deliveryID, Kind: KindWebhook}
+	return p.publishJSON(ctx, deliveryID, msg)
+}
+
+func (p *Producer) PublishWebhookRetry(ctx context.Context, deliveryID string, nextRetryAt int64) error {
+	msg := RetryMessage{TaskID: deliveryID, NextRetryAt: nextRetryAt, Kind: KindWebhook}
+	return p.publishJSON(ctx, deliveryID, msg)
+}
+
 func (p *Producer) publishJSON(ctx context.Context, key string, v any) error {
 	b, err := json.Marshal(v)
 	if err != nil {
@@ -1,11 +1,20 @@
 package kafkaproducer
 
+// Kind tells consumers which record TaskID refers to.
+// Empty means a notification task (the original message format).
+const (
+	KindTask    = ""
+	KindWebhook = "webhook" // TaskID holds a webhook delivery_id
+)
+
 type TaskMessage struct {
 	TaskID string `json:"task_id"`
+	Kind   string `json:"kind,omitempty"`
 }
 
 // RetryMessage includes when it should be retried.
 type RetryMessage struct {
 	TaskID      string `json:"task_id"`
 	NextRetryAt int64  `json:"next_retry_at"` // epoch ms
+	Kind        string `json:"kind,omitempty"`
 }
@@ -0,0 +1,116 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"time"
+
+	"safe-notify/internal/models"
+)
+
+// Rule is a token bucket: Rate sends per second, bursting up to Burst
+// (default: Rate rounded up).
+type Rule struct {
+	Rate  float64 `json:"rate"`
+	Burst int     `json:"burst"`
+}
+
+func (r Rule) burst() int {
+	if r.Burst > 0 {
+		return r.Burst
+	}
+	return int(math.Ceil(r.Rate))
+}
+
+// Buckets is the store side: a token bucket shared by every worker.
+type Buckets interface {
+	TakeToken(ctx context.Context, key string, rate float64, burst int, nowMs int64) (int64, error)
+	ReturnToken(ctx context.Context, key string, burst int) error
+}
+
+// Limiter checks a task against every rule that applies to it. Rules are
+// keyed by scope:
+//
+//	provider:<CHANNEL>  all sends through a channel's provider, e.g. provider:EMAIL for SES
+//	domain:<domain>     EMAIL sends from a sending domain, e.g. domain:example.com
+//	tenant:<id>         all sends for one tenant
+type Limiter struct {
+	Buckets       Buckets
+	Rules         map[string]Rule
+	SendingDomain string            // domain of the EMAIL From address
+	TenantDomains map[string]string // tenant -> domain of its own From address, if it has one
+}
+
+// Keys returns the rule keys a task is counted against, narrowest first.
+func (l *Limiter) Keys(task models.Task, tenantID string) []string {
+	var keys []string
+	if tenantID != "" {
+		keys = append(keys, "tenant:"+tenantID)
+	}
+	domain := l.SendingDomain
+	if d, ok := l.TenantDomains[tenantID]; ok {
+		domain = d
+	}
+	if task.Channel == "EMAIL" && domain != "" {
+		keys = append(keys, "domain:"+strings.ToLower(domain))
+	}
+	return append(keys, "provider:"+task.Channel)
+}
+
+// Reserve takes a token from every bucket that applies and returns 0, or
+// returns how long to wait and the key that is exhausted. If a bucket
+// refuses, the tokens already taken from narrower ones are given back, so a
+// send held up by a wider limit doesn't use up its tenant's share.
+func (l *Limiter) Reserve(ctx context.Context, task models.Task, tenantID string) (time.Duration, string, error) {
+	type taken struct {
+		key   string
+		burst int
+	}
+	var took []taken
+	refund := func() error {
+		for _, t := range took {
+			if err := l.Buckets.ReturnToken(ctx, t.key, t.burst); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, key := range l.Keys(task, tenantID) {
+		rule, ok := l.Rules[key]
+		if !ok || rule.Rate <= 0 {
+			continue
+		}
+		burst := rule.burst()
+
+		waitMs, err := l.Buckets.TakeToken(ctx, key, rule.Rate, burst, time.Now().UnixMilli())
+		if err != nil {
+			if rerr := refund(); rerr != nil {
+				return 0, "", errors.Join(err, rerr)
+			}
+			return 0, "", err
+		}
+		if waitMs > 0 {
+			return time.Duration(waitMs) * time.Millisecond, key, refund()
+		}
+		took = append(took, taken{key, burst})
+	}
+	return 0, "", nil
+}
+
+// Release gives back the tokens a Reserve that returned 0 took for task,
+// e.g. when the send is then held up by something else. Every bucket is
+// tried; the errors are joined.
+func (l *Limiter) Release(ctx context.Context, task models.Task, tenantID string) error {
+	var errs []error
+	for _, key := range l.Keys(task, tenantID) {
+		rule, ok := l.Rules[key]
+		if !ok || rule.Rate <= 0 {
+			continue
+		}
+		errs = append(errs, l.Buckets.ReturnToken(ctx, key, rule.burst()))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,143 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"safe-notify/internal/models"
+)
+
+// fakeBuckets hands out tokens per key and counts what is returned.
+type fakeBuckets struct {
+	tokens   map[string]int
+	returned map[string]int
+	fail     string // TakeToken errors for this key
+}
+
+func (f *fakeBuckets) TakeToken(_ context.Context, key string, _ float64, _ int, _ int64) (int64, error) {
+	if key == f.fail {
+		return 0, errors.New("store unavailable")
+	}
+	if f.tokens[key] < 1 {
+		return 250, nil
+	}
+	f.tokens[key]--
+	return 0, nil
+}
+
+func (f *fakeBuckets) ReturnToken(_ context.Context, key string, _ int) error {
+	f.tokens[key]++
+	f.returned[key]++
+	return nil
+}
+
+func newLimiter(b *fakeBuckets) *Limiter {
+	return &Limiter{
+		Buckets: b,
+		Rules: map[string]Rule{
+			"tenant:acme":        {Rate: 10},
+			"domain:example.com": {Rate: 10},
+			"provider:EMAIL":     {Rate: 10},
+		},
+		SendingDomain: "Example.com",
+	}
+}
+
+func TestKeys(t *testing.T) {
+	l := newLimiter(nil)
+	l.TenantDomains = map[string]string{"own": "own.example"}
+
+	email := models.Task{Channel: "EMAIL"}
+	got := l.Keys(email, "acme")
+	want := []string{"tenant:acme", "domain:example.com", "provider:EMAIL"}
+	if len(got) != len(want) {
+		t.Fatalf("Keys = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Keys = %v, want %v", got, want)
+		}
+	}
+
+	if got := l.Keys(email, "own"); got[1] != "domain:own.example" {
+		t.Errorf("tenant domain not used: %v", got)
+	}
+	if got := l.Keys(models.Task{Channel: "SMS"}, "acme"); len(got) != 2 || got[1] != "provider:SMS" {
+		t.Errorf("SMS keys = %v", got)
+	}
+}
+
+func TestReserveTakesFromEveryBucket(t *testing.T) {
+	b := &fakeBuckets{
+		tokens:   map[string]int{"tenant:acme": 1, "domain:example.com": 1, "provider:EMAIL": 1},
+		returned: map[string]int{},
+	}
+	wait, key, err := newLimiter(b).Reserve(context.Background(), models.Task{Channel: "EMAIL"}, "acme")
+	if err != nil || wait != 0 || key != "" {
+		t.Fatalf("Reserve = %v, %q, %v; want a token", wait, key, err)
+	}
+	for k, n := range b.tokens {
+		if n != 0 {
+			t.Errorf("%s has %d tokens left, want 0", k, n)
+		}
+	}
+}
+
+func TestReserveRefundsWhenAWiderBucketRefuses(t *testing.T) {
+	b := &fakeBuckets{
+		tokens:   map[string]int{"tenant:acme": 5, "domain:example.com": 5, "provider:EMAIL": 0},
+		returned: map[string]int{},
+	}
+	wait, key, err := newLimiter(b).Reserve(context.Background(), models.Task{Channel: "EMAIL"}, "acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key != "provider:EMAIL" || wait != 250*time.Millisecond {
+		t.Errorf("Reserve = %v, %q; want 250ms on provider:EMAIL", wait, key)
+	}
+	if b.tokens["tenant:acme"] != 5 || b.tokens["domain:example.com"] != 5 {
+		t.Errorf("tokens = %v, want the narrower buckets refunded", b.tokens)
+	}
+	if b.returned["provider:EMAIL"] != 0 {
+		t.Errorf("returned a token to the bucket that refused")
+	}
+}
+
+func TestReserveRefundsOnError(t *testing.T) {
+	b := &fakeBuckets{
+		tokens:   map[string]int{"tenant:acme": 5, "domain:example.com": 5, "provider:EMAIL": 5},
+		returned: map[string]int{},
+		fail:     "domain:example.com",
+	}
+	if _, _, err := newLimiter(b).Reserve(context.Background(), models.Task{Channel: "EMAIL"}, "acme"); err == nil {
+		t.Fatal("Reserve succeeded, want the store error")
+	}
+	if b.tokens["tenant:acme"] != 5 || b.returned["tenant:acme"] != 1 {
+		t.Errorf("tenant bucket not refunded: tokens %v, returned %v", b.tokens, b.returned)
+	}
+	if b.tokens["provider:EMAIL"] != 5 {
+		t.Errorf("provider bucket touched after the error")
+	}
+}
+
+func TestReleaseReturnsEveryToken(t *testing.T) {
+	b := &fakeBuckets{
+		tokens:   map[string]int{"tenant:acme": 1, "domain:example.com": 1, "provider:EMAIL": 1},
+		returned: map[string]int{},
+	}
+	l := newLimiter(b)
+	task := models.Task{Channel: "EMAIL"}
+	if wait, _, err := l.Reserve(context.Background(), task, "acme"); err != nil || wait != 0 {
+		t.Fatalf("Reserve = %v, %v", wait, err)
+	}
+	if err := l.Release(context.Background(), task, "acme"); err != nil {
+		t.Fatal(err)
+	}
+	for k, n := range b.tokens {
+		if n != 1 || b.returned[k] != 1 {
+			t.Errorf("%s: %d tokens, %d returned; want 1 and 1", k, n, b.returned[k])
+		}
+	}
+}
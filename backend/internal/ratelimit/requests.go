@@ -0,0 +1,141 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Window is a fixed-window request limit: at most Limit requests every
+// WindowSeconds. Windows start at multiples of WindowSeconds.
+type Window struct {
+	Limit         int `json:"limit"`
+	WindowSeconds int `json:"windowSeconds"`
+}
+
+// RouteLimit holds the limits for one route. Each applies separately, so a
+// request must fit all of them: Key per API key, Tenant across all of a
+// tenant's keys, IP per client address (checked before authentication).
+type RouteLimit struct {
+	Key    *Window `json:"key"`
+	Tenant *Window `json:"tenant"`
+	IP     *Window `json:"ip"`
+}
+
+// RouteLimits maps a route, "METHOD /pattern" as registered (e.g.
+// "POST /events", "GET /events/{event_id}"), to its limits. "*" holds the
+// defaults for every route; a route's own entry overrides them field by
+// field, and a limit of 0 turns one off.
+type RouteLimits map[string]RouteLimit
+
+// For returns the effective limits of route. Nil fields are unlimited.
+func (rl RouteLimits) For(route string) RouteLimit {
+	out := rl["*"]
+	if r, ok := rl[route]; ok {
+		if r.Key != nil {
+			out.Key = r.Key
+		}
+		if r.Tenant != nil {
+			out.Tenant = r.Tenant
+		}
+		if r.IP != nil {
+			out.IP = r.IP
+		}
+	}
+	for _, w := range []**Window{&out.Key, &out.Tenant, &out.IP} {
+		if *w != nil && (*w).Limit <= 0 {
+			*w = nil
+		}
+	}
+	return out
+}
+
+// Validate rejects windows without a length.
+func (rl RouteLimits) Validate() error {
+	for route, r := range rl {
+		for name, w := range map[string]*Window{"key": r.Key, "tenant": r.Tenant, "ip": r.IP} {
+			if w != nil && w.Limit > 0 && w.WindowSeconds <= 0 {
+				return fmt.Errorf("%s: %s: windowSeconds must be > 0", route, name)
+			}
+		}
+	}
+	return nil
+}
+
+// Counters count requests per key and window. Incr adds one to key and
+// returns the new count; the count may be forgotten after expiresAtMs.
+type Counters interface {
+	Incr(ctx context.Context, key string, expiresAtMs int64) (int64, error)
+}
+
+// Result is the state of one window after a request was counted, in the
+// shape of the RateLimit-* response headers.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	Reset     time.Duration // until the window ends
+}
+
+// Hit counts one request against key's current window.
+func (w Window) Hit(ctx context.Context, c Counters, key string, now time.Time) (Result, error) {
+	windowMs := int64(w.WindowSeconds) * 1000
+	nowMs := now.UnixMilli()
+	end := nowMs - nowMs%windowMs + windowMs
+
+	n, err := c.Incr(ctx, fmt.Sprintf("%s|%d", key, end), end)
+	if err != nil {
+		return Result{}, err
+	}
+	res := Result{
+		Allowed: n <= int64(w.Limit),
+		Limit:   w.Limit,
+		Reset:   time.Duration(end-nowMs) * time.Millisecond,
+	}
+	if !res.Allowed {
+		return res, nil
+	}
+	res.Remaining = w.Limit - int(n)
+	return res, nil
+}
+
+// Memory keeps counters in process, for a single API replica. Use the
+// store's counters when several replicas share the limits.
+type Memory struct {
+	mu        sync.Mutex
+	counts    map[string]memoryCount
+	nextSweep int64
+}
+
+type memoryCount struct {
+	n         int64
+	expiresAt int64
+}
+
+func NewMemory() *Memory {
+	return &Memory{counts: map[string]memoryCount{}}
+}
+
+func (m *Memory) Incr(_ context.Context, key string, expiresAtMs int64) (int64, error) {
+	now := time.Now().UnixMilli()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Drop finished windows now and then, so idle keys don't pile up
+	if now >= m.nextSweep {
+		for k, c := range m.counts {
+			if c.expiresAt <= now {
+				delete(m.counts, k)
+			}
+		}
+		m.nextSweep = now + time.Minute.Milliseconds()
+	}
+
+	c := m.counts[key]
+	c.n++
+	c.expiresAt = expiresAtMs
+	m.counts[key] = c
+	return c.n, nil
+}
@@ -0,0 +1,78 @@
+package store
+
+import (
+	"context"
+
+	"safe-notify/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// The API keys table is keyed by key_id.
+
+func (s *DynamoStore) PutAPIKey(ctx context.Context, k models.APIKey) error {
+	item, err := attributevalue.MarshalMap(k)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.apiKeysTable),
+		Item:      item,
+	})
+	return err
+}
+
+func (s *DynamoStore) GetAPIKey(ctx context.Context, keyID string) (*models.APIKey, error) {
+	out, err := s.db.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(s.apiKeysTable),
+		Key: map[string]types.AttributeValue{
+			"key_id": &types.AttributeValueMemberS{Value: keyID},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if out.Item == nil {
+		return nil, nil
+	}
+
+	var k models.APIKey
+	if err := attributevalue.UnmarshalMap(out.Item, &k); err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
+
+func (s *DynamoStore) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	p := dynamodb.NewScanPaginator(s.db, &dynamodb.ScanInput{
+		TableName: aws.String(s.apiKeysTable),
+	})
+
+	var out []models.APIKey
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var items []models.APIKey
+		if err := attributevalue.UnmarshalListOfMaps(page.Items, &items); err != nil {
+			return nil, err
+		}
+		out = append(out, items...)
+	}
+	return out, nil
+}
+
+func (s *DynamoStore) DeleteAPIKey(ctx context.Context, keyID string) error {
+	_, err := s.db.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(s.apiKeysTable),
+		Key: map[string]types.AttributeValue{
+			"key_id": &types.AttributeValueMemberS{Value: keyID},
+		},
+	})
+	return err
+}
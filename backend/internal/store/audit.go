@@ -0,0 +1,96 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"safe-notify/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// The audit table is keyed by audit_id. It is append-only: PutAuditRecord
+// never overwrites, and nothing here updates or deletes. Deny
+// dynamodb:UpdateItem and DeleteItem on it in the API's IAM policy to make
+// that hold outside this code too.
+
+func (s *DynamoStore) PutAuditRecord(ctx context.Context, rec models.AuditRecord) error {
+	item, err := attributevalue.MarshalMap(rec)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(s.auditTable),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(audit_id)"),
+	})
+	return err
+}
+
+// QueryAudit returns up to limit records matching f, newest first. The
+// table is scanned in full, so narrow the time range on large trails.
+func (s *DynamoStore) QueryAudit(ctx context.Context, f models.AuditFilter, limit int) ([]models.AuditRecord, error) {
+	// Filtered attributes always go through names: ACTION and AT are
+	// reserved words, and the next one added may be too.
+	values := map[string]types.AttributeValue{}
+	names := map[string]string{}
+	var conds []string
+	if f.TenantID != "" {
+		conds = append(conds, tenantCond(f.TenantID, values))
+	}
+	for _, c := range []struct{ attr, v string }{
+		{"actor", f.Actor},
+		{"action", f.Action},
+		{"target_id", f.TargetID},
+	} {
+		if c.v != "" {
+			conds = append(conds, fmt.Sprintf("#%s = :%s", c.attr, c.attr))
+			names["#"+c.attr] = c.attr
+			values[":"+c.attr] = &types.AttributeValueMemberS{Value: c.v}
+		}
+	}
+	if f.FromMs > 0 {
+		conds = append(conds, "#at >= :from")
+		names["#at"] = "at"
+		values[":from"] = &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", f.FromMs)}
+	}
+	if f.ToMs > 0 {
+		conds = append(conds, "#at < :to")
+		names["#at"] = "at"
+		values[":to"] = &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", f.ToMs)}
+	}
+
+	in := &dynamodb.ScanInput{TableName: aws.String(s.auditTable)}
+	if len(conds) > 0 {
+		in.FilterExpression = aws.String(strings.Join(conds, " AND "))
+		in.ExpressionAttributeValues = values
+	}
+	if len(names) > 0 {
+		in.ExpressionAttributeNames = names
+	}
+
+	var out []models.AuditRecord
+	p := dynamodb.NewScanPaginator(s.db, in)
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var items []models.AuditRecord
+		if err := attributevalue.UnmarshalListOfMaps(page.Items, &items); err != nil {
+			return nil, err
+		}
+		out = append(out, items...)
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].At > out[j].At })
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
@@ -0,0 +1,62 @@
+package store
+
+import (
+	"context"
+
+	"safe-notify/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// The devices table is keyed by user_id (hash, tenant-scoped, see tenantKey)
+// + device_token (range).
+
+func (s *DynamoStore) PutDevice(ctx context.Context, d models.Device) error {
+	d.UserID = tenantKey(d.TenantID, d.UserID)
+	item, err := attributevalue.MarshalMap(d)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.devicesTable),
+		Item:      item,
+	})
+	return err
+}
+
+func (s *DynamoStore) ListDevices(ctx context.Context, tenantID, userID string) ([]models.Device, error) {
+	out, err := s.db.Query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(s.devicesTable),
+		KeyConditionExpression: aws.String("user_id = :u"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":u": &types.AttributeValueMemberS{Value: tenantKey(tenantID, userID)},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var devices []models.Device
+	if err := attributevalue.UnmarshalListOfMaps(out.Items, &devices); err != nil {
+		return nil, err
+	}
+	for i := range devices {
+		devices[i].TenantID, devices[i].UserID = models.TenantOrDefault(tenantID), userID
+	}
+	return devices, nil
+}
+
+func (s *DynamoStore) DeleteDevice(ctx context.Context, tenantID, userID, token string) error {
+	_, err := s.db.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(s.devicesTable),
+		Key: map[string]types.AttributeValue{
+			"user_id":      &types.AttributeValueMemberS{Value: tenantKey(tenantID, userID)},
+			"device_token": &types.AttributeValueMemberS{Value: token},
+		},
+	})
+	return err
+}
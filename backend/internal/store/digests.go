@@ -0,0 +1,135 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"safe-notify/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// The digests table is keyed by digest_key.
+
+// AppendDigest adds entry to the digest window d, creating the window on
+// first use. It returns false if the window has already closed.
+func (s *DynamoStore) AppendDigest(ctx context.Context, d models.Digest, entry models.DigestEntry) (bool, error) {
+	av, err := attributevalue.Marshal(entry)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = s.db.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(s.digestsTable),
+		Key: map[string]types.AttributeValue{
+			"digest_key": &types.AttributeValueMemberS{Value: d.DigestKey},
+		},
+		ConditionExpression: aws.String("attribute_not_exists(closed_at)"),
+		UpdateExpression: aws.String("SET entries = list_append(if_not_exists(entries, :empty), :e), " +
+			"digest_task_id = :id, tenant_id = :t, channel = :ch, recipient = :r, event_type = :evt, closes_at = :c"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":empty": &types.AttributeValueMemberL{Value: []types.AttributeValue{}},
+			":e":     &types.AttributeValueMemberL{Value: []types.AttributeValue{av}},
+			":id":    &types.AttributeValueMemberS{Value: d.DigestTaskID},
+			":t":     &types.AttributeValueMemberS{Value: models.TenantOrDefault(d.TenantID)},
+			":ch":    &types.AttributeValueMemberS{Value: d.Channel},
+			":r":     &types.AttributeValueMemberS{Value: d.Recipient},
+			":evt":   &types.AttributeValueMemberS{Value: d.EventType},
+			":c":     &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", d.ClosesAt)},
+		},
+	})
+	if err != nil {
+		var cfe *types.ConditionalCheckFailedException
+		if errors.As(err, &cfe) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// FetchDueDigests returns windows past closes_at whose digest task hasn't
+// been queued yet (including ones closed by a scheduler that then died).
+func (s *DynamoStore) FetchDueDigests(ctx context.Context, nowMs int64) ([]models.Digest, error) {
+	p := dynamodb.NewScanPaginator(s.db, &dynamodb.ScanInput{
+		TableName:        aws.String(s.digestsTable),
+		FilterExpression: aws.String("closes_at <= :now AND attribute_not_exists(dispatched_at)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":now": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", nowMs)},
+		},
+	})
+
+	var out []models.Digest
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var items []models.Digest
+		if err := attributevalue.UnmarshalListOfMaps(page.Items, &items); err != nil {
+			return nil, err
+		}
+		out = append(out, items...)
+	}
+	return out, nil
+}
+
+// CloseDigest stops further appends and returns the final entries. Closing
+// an already-closed window just returns it again.
+func (s *DynamoStore) CloseDigest(ctx context.Context, digestKey string, nowMs int64) (*models.Digest, error) {
+	_, err := s.db.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(s.digestsTable),
+		Key: map[string]types.AttributeValue{
+			"digest_key": &types.AttributeValueMemberS{Value: digestKey},
+		},
+		ConditionExpression: aws.String("attribute_exists(digest_key) AND attribute_not_exists(closed_at)"),
+		UpdateExpression:    aws.String("SET closed_at = :now"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":now": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", nowMs)},
+		},
+	})
+	if err != nil {
+		var cfe *types.ConditionalCheckFailedException
+		if !errors.As(err, &cfe) {
+			return nil, err
+		}
+	}
+
+	out, err := s.db.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(s.digestsTable),
+		ConsistentRead: aws.Bool(true),
+		Key: map[string]types.AttributeValue{
+			"digest_key": &types.AttributeValueMemberS{Value: digestKey},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if out.Item == nil {
+		return nil, nil
+	}
+
+	var d models.Digest
+	if err := attributevalue.UnmarshalMap(out.Item, &d); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (s *DynamoStore) MarkDigestDispatched(ctx context.Context, digestKey string, nowMs int64) error {
+	_, err := s.db.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(s.digestsTable),
+		Key: map[string]types.AttributeValue{
+			"digest_key": &types.AttributeValueMemberS{Value: digestKey},
+		},
+		UpdateExpression: aws.String("SET dispatched_at = :now"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":now": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", nowMs)},
+		},
+	})
+	return err
+}
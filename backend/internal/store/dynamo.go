@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strings"
 
 	"safe-notify/internal/models"
 
@@ -16,8 +17,22 @@ import (
 )
 
 type DynamoStore struct {
-	db        *dynamodb.Client
-	tableName string
+	db             *dynamodb.Client
+	tableName      string
+	prefsTable     string
+	suppTable      string
+	hooksTable     string
+	hookDlvTable   string
+	devicesTable   string
+	inboxTable     string
+	eventsTable    string
+	schedulesTable string
+	digestsTable   string
+	throttleTable  string
+	rateLimitTable string
+	apiKeysTable   string
+	usageTable     string
+	auditTable     string
 }
 
 func NewDynamoStore(ctx context.Context) (*DynamoStore, error) {
@@ -44,7 +59,32 @@ func NewDynamoStore(ctx context.Context) (*DynamoStore, error) {
 		}
 	})
 
-	return &DynamoStore{db: client, tableName: table}, nil
+	return &DynamoStore{
+		db:             client,
+		tableName:      table,
+		prefsTable:     getenv("DYNAMO_PREFERENCES_TABLE", "safe-notify-preferences"),
+		suppTable:      getenv("DYNAMO_SUPPRESSIONS_TABLE", "safe-notify-suppressions"),
+		hooksTable:     getenv("DYNAMO_WEBHOOKS_TABLE", "safe-notify-webhooks"),
+		hookDlvTable:   getenv("DYNAMO_WEBHOOK_DELIVERIES_TABLE", "safe-notify-webhook-deliveries"),
+		devicesTable:   getenv("DYNAMO_DEVICES_TABLE", "safe-notify-devices"),
+		inboxTable:     getenv("DYNAMO_INBOX_TABLE", "safe-notify-inbox"),
+		eventsTable:    getenv("DYNAMO_EVENTS_TABLE", "safe-notify-events"),
+		schedulesTable: getenv("DYNAMO_SCHEDULES_TABLE", "safe-notify-schedules"),
+		digestsTable:   getenv("DYNAMO_DIGESTS_TABLE", "safe-notify-digests"),
+		throttleTable:  getenv("DYNAMO_THROTTLE_TABLE", "safe-notify-throttle"),
+		rateLimitTable: getenv("DYNAMO_RATE_LIMIT_TABLE", "safe-notify-rate-limits"),
+		apiKeysTable:   getenv("DYNAMO_API_KEYS_TABLE", "safe-notify-api-keys"),
+		usageTable:     getenv("DYNAMO_USAGE_TABLE", "safe-notify-usage"),
+		auditTable:     getenv("DYNAMO_AUDIT_TABLE", "safe-notify-audit"),
+	}, nil
+}
+
+func getenv(k, def string) string {
+	v := os.Getenv(k)
+	if v == "" {
+		return def
+	}
+	return v
 }
 
 func (s *DynamoStore) PutTask(ctx context.Context, t models.Task) error {
@@ -60,20 +100,55 @@ func (s *DynamoStore) PutTask(ctx context.Context, t models.Task) error {
 	return err
 }
 
-func (s *DynamoStore) ListTasks(ctx context.Context, limit int32) ([]models.Task, error) {
-	out, err := s.db.Scan(ctx, &dynamodb.ScanInput{
-		TableName: aws.String(s.tableName),
-		Limit:     aws.Int32(limit),
+// CreateTask is PutTask that refuses to overwrite an existing task_id.
+// It returns false if the task already exists.
+func (s *DynamoStore) CreateTask(ctx context.Context, t models.Task) (bool, error) {
+	item, err := attributevalue.MarshalMap(t)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = s.db.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(s.tableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(task_id)"),
 	})
 	if err != nil {
-		return nil, err
+		var cfe *types.ConditionalCheckFailedException
+		if errors.As(err, &cfe) {
+			return false, nil
+		}
+		return false, err
 	}
+	return true, nil
+}
 
+// ListTasks returns up to limit of tenantID's tasks.
+func (s *DynamoStore) ListTasks(ctx context.Context, tenantID string, limit int32) ([]models.Task, error) {
+	values := map[string]types.AttributeValue{}
+	p := dynamodb.NewScanPaginator(s.db, &dynamodb.ScanInput{
+		TableName:                 aws.String(s.tableName),
+		Limit:                     aws.Int32(limit),
+		FilterExpression:          aws.String(tenantCond(tenantID, values)),
+		ExpressionAttributeValues: values,
+	})
+
+	// Limit caps items read, not matched, so keep paging until full
 	var tasks []models.Task
-	if err := attributevalue.UnmarshalListOfMaps(out.Items, &tasks); err != nil {
-		return nil, err
+	for p.HasMorePages() && len(tasks) < int(limit) {
+		out, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var page []models.Task
+		if err := attributevalue.UnmarshalListOfMaps(out.Items, &page); err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, page...)
+	}
+	if len(tasks) > int(limit) {
+		tasks = tasks[:limit]
 	}
-
 	return tasks, nil
 }
 
@@ -223,6 +298,92 @@ func (s *DynamoStore) UpdateForRetry(
 	return err
 }
 
+// DeferTask hands a claimed task back to the scheduler until sendAtMs
+// without spending an attempt (e.g. the recipient's quiet hours).
+func (s *DynamoStore) DeferTask(ctx context.Context, taskID string, sendAtMs int64, reason string, updatedAt int64) error {
+	_, err := s.db.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(s.tableName),
+		Key: map[string]types.AttributeValue{
+			"task_id": &types.AttributeValueMemberS{Value: taskID},
+		},
+		UpdateExpression: aws.String(
+			"SET #st=:scheduled, send_at=:sa, next_retry_at=:sa, last_error=:le, updated_at=:ua " +
+				"REMOVE worker_id, processing_started_at",
+		),
+		ExpressionAttributeNames: map[string]string{
+			"#st": "status",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":scheduled": &types.AttributeValueMemberS{Value: "SCHEDULED"},
+			":sa":        &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", sendAtMs)},
+			":le":        &types.AttributeValueMemberS{Value: reason},
+			":ua":        &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", updatedAt)},
+		},
+	})
+	return err
+}
+
+// RequeueTask releases a claimed task to be retried at nextRetryAt without
+// counting an attempt (e.g. a provider rate limit was hit before sending).
+func (s *DynamoStore) RequeueTask(ctx context.Context, taskID string, nextRetryAt int64, reason string, updatedAt int64) error {
+	_, err := s.db.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(s.tableName),
+		Key: map[string]types.AttributeValue{
+			"task_id": &types.AttributeValueMemberS{Value: taskID},
+		},
+		UpdateExpression: aws.String(
+			"SET #st=:pending, next_retry_at=:nra, last_error=:le, updated_at=:ua " +
+				"REMOVE worker_id, processing_started_at",
+		),
+		ExpressionAttributeNames: map[string]string{
+			"#st": "status",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pending": &types.AttributeValueMemberS{Value: "PENDING"},
+			":nra":     &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", nextRetryAt)},
+			":le":      &types.AttributeValueMemberS{Value: reason},
+			":ua":      &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", updatedAt)},
+		},
+	})
+	return err
+}
+
+// ReleaseTask returns a task workerID still has claimed to FAILED, due now
+// and with its attempt count unchanged, so the next delivery of its message
+// can claim it again. It returns false if the claim has already moved on.
+func (s *DynamoStore) ReleaseTask(ctx context.Context, taskID, workerID, reason string, updatedAt int64) (bool, error) {
+	_, err := s.db.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(s.tableName),
+		Key: map[string]types.AttributeValue{
+			"task_id": &types.AttributeValueMemberS{Value: taskID},
+		},
+		ConditionExpression: aws.String("#st = :processing AND worker_id = :wid"),
+		UpdateExpression: aws.String(
+			"SET #st=:failed, next_retry_at=:zero, last_error=:le, updated_at=:ua " +
+				"REMOVE worker_id, processing_started_at",
+		),
+		ExpressionAttributeNames: map[string]string{
+			"#st": "status",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":processing": &types.AttributeValueMemberS{Value: "PROCESSING"},
+			":wid":        &types.AttributeValueMemberS{Value: workerID},
+			":failed":     &types.AttributeValueMemberS{Value: "FAILED"},
+			":zero":       &types.AttributeValueMemberN{Value: "0"},
+			":le":         &types.AttributeValueMemberS{Value: reason},
+			":ua":         &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", updatedAt)},
+		},
+	})
+	if err != nil {
+		var cfe *types.ConditionalCheckFailedException
+		if errors.As(err, &cfe) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
 func (s *DynamoStore) ResetForReplay(ctx context.Context, taskID string, updatedAt int64) error {
 	_, err := s.db.UpdateItem(ctx, &dynamodb.UpdateItemInput{
 		TableName: aws.String(s.tableName),
@@ -246,3 +407,107 @@ func (s *DynamoStore) ResetForReplay(ctx context.Context, taskID string, updated
 	})
 	return err
 }
+
+// TransitionStatus moves a task to newStatus only if it is currently in one
+// of from. It returns false if the task is missing or in another state.
+func (s *DynamoStore) TransitionStatus(ctx context.Context, taskID string, from []string, newStatus string, nowMs int64) (bool, error) {
+	values := map[string]types.AttributeValue{
+		":to": &types.AttributeValueMemberS{Value: newStatus},
+		":u":  &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", nowMs)},
+	}
+	conds := make([]string, 0, len(from))
+	for i, st := range from {
+		k := fmt.Sprintf(":from%d", i)
+		values[k] = &types.AttributeValueMemberS{Value: st}
+		conds = append(conds, "#st = "+k)
+	}
+
+	_, err := s.db.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(s.tableName),
+		Key: map[string]types.AttributeValue{
+			"task_id": &types.AttributeValueMemberS{Value: taskID},
+		},
+		ConditionExpression: aws.String(strings.Join(conds, " OR ")),
+		UpdateExpression:    aws.String("SET #st = :to, updated_at = :u"),
+		ExpressionAttributeNames: map[string]string{
+			"#st": "status",
+		},
+		ExpressionAttributeValues: values,
+	})
+	if err != nil {
+		var cfe *types.ConditionalCheckFailedException
+		if errors.As(err, &cfe) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// FetchDueScheduledTasks returns SCHEDULED tasks whose send_at has passed.
+func (s *DynamoStore) FetchDueScheduledTasks(ctx context.Context, nowMs int64, limit int) ([]models.Task, error) {
+	p := dynamodb.NewScanPaginator(s.db, &dynamodb.ScanInput{
+		TableName:        aws.String(s.tableName),
+		FilterExpression: aws.String("#st = :scheduled AND send_at <= :now"),
+		ExpressionAttributeNames: map[string]string{
+			"#st": "status",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":scheduled": &types.AttributeValueMemberS{Value: "SCHEDULED"},
+			":now":       &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", nowMs)},
+		},
+	})
+
+	var tasks []models.Task
+	for p.HasMorePages() && len(tasks) < limit {
+		out, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var page []models.Task
+		if err := attributevalue.UnmarshalListOfMaps(out.Items, &page); err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, page...)
+	}
+	return tasks, nil
+}
+
+// FetchTasksByEntity returns tenantID's tasks for entityID whose status is
+// one of statuses (all tasks if statuses is empty).
+func (s *DynamoStore) FetchTasksByEntity(ctx context.Context, tenantID, entityID string, statuses []string) ([]models.Task, error) {
+	values := map[string]types.AttributeValue{
+		":e": &types.AttributeValueMemberS{Value: entityID},
+	}
+	filter := "entity_id = :e AND " + tenantCond(tenantID, values)
+	in := &dynamodb.ScanInput{
+		TableName:                 aws.String(s.tableName),
+		ExpressionAttributeValues: values,
+	}
+	if len(statuses) > 0 {
+		conds := make([]string, 0, len(statuses))
+		for i, st := range statuses {
+			k := fmt.Sprintf(":s%d", i)
+			values[k] = &types.AttributeValueMemberS{Value: st}
+			conds = append(conds, "#st = "+k)
+		}
+		filter += " AND (" + strings.Join(conds, " OR ") + ")"
+		in.ExpressionAttributeNames = map[string]string{"#st": "status"}
+	}
+	in.FilterExpression = aws.String(filter)
+
+	var tasks []models.Task
+	p := dynamodb.NewScanPaginator(s.db, in)
+	for p.HasMorePages() {
+		out, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var page []models.Task
+		if err := attributevalue.UnmarshalListOfMaps(out.Items, &page); err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, page...)
+	}
+	return tasks, nil
+}
@@ -0,0 +1,125 @@
+package store
+
+import (
+	"context"
+	"errors"
+
+	"safe-notify/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func (s *DynamoStore) PutEvent(ctx context.Context, e models.Event) error {
+	item, err := attributevalue.MarshalMap(e)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.eventsTable),
+		Item:      item,
+	})
+	return err
+}
+
+// CreateEvent is PutEvent that refuses to overwrite an existing event_id,
+// whose task_ids may since have had fallbacks appended. It returns false if
+// the event already exists.
+func (s *DynamoStore) CreateEvent(ctx context.Context, e models.Event) (bool, error) {
+	item, err := attributevalue.MarshalMap(e)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = s.db.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(s.eventsTable),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(event_id)"),
+	})
+	if err != nil {
+		var cfe *types.ConditionalCheckFailedException
+		if errors.As(err, &cfe) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *DynamoStore) GetEvent(ctx context.Context, eventID string) (*models.Event, error) {
+	out, err := s.db.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(s.eventsTable),
+		Key: map[string]types.AttributeValue{
+			"event_id": &types.AttributeValueMemberS{Value: eventID},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if out.Item == nil {
+		return nil, nil
+	}
+
+	var e models.Event
+	if err := attributevalue.UnmarshalMap(out.Item, &e); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// GetTasksByIDs batch-loads tasks; missing IDs are skipped.
+func (s *DynamoStore) GetTasksByIDs(ctx context.Context, taskIDs []string) ([]models.Task, error) {
+	var tasks []models.Task
+
+	for start := 0; start < len(taskIDs); start += 100 { // BatchGetItem limit
+		end := min(start+100, len(taskIDs))
+
+		keys := make([]map[string]types.AttributeValue, 0, end-start)
+		for _, id := range taskIDs[start:end] {
+			keys = append(keys, map[string]types.AttributeValue{
+				"task_id": &types.AttributeValueMemberS{Value: id},
+			})
+		}
+
+		req := map[string]types.KeysAndAttributes{s.tableName: {Keys: keys}}
+		for len(req) > 0 {
+			out, err := s.db.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{RequestItems: req})
+			if err != nil {
+				return nil, err
+			}
+			var page []models.Task
+			if err := attributevalue.UnmarshalListOfMaps(out.Responses[s.tableName], &page); err != nil {
+				return nil, err
+			}
+			tasks = append(tasks, page...)
+			req = out.UnprocessedKeys
+		}
+	}
+	return tasks, nil
+}
+
+// AppendEventTask links a task created after the event (e.g. a fallback).
+func (s *DynamoStore) AppendEventTask(ctx context.Context, eventID, taskID string) error {
+	_, err := s.db.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(s.eventsTable),
+		Key: map[string]types.AttributeValue{
+			"event_id": &types.AttributeValueMemberS{Value: eventID},
+		},
+		ConditionExpression: aws.String("attribute_exists(event_id) AND NOT contains(task_ids, :tid)"),
+		UpdateExpression:    aws.String("SET task_ids = list_append(task_ids, :new)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":tid": &types.AttributeValueMemberS{Value: taskID},
+			":new": &types.AttributeValueMemberL{Value: []types.AttributeValue{
+				&types.AttributeValueMemberS{Value: taskID},
+			}},
+		},
+	})
+	var cfe *types.ConditionalCheckFailedException
+	if errors.As(err, &cfe) {
+		return nil // already linked, or an old task without a parent event
+	}
+	return err
+}
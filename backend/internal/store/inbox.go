@@ -0,0 +1,183 @@
+package store
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"safe-notify/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// The inbox table is keyed by user_id (hash, tenant-scoped, see tenantKey) +
+// item_id (range).
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// PutInboxItem writes the item once; a retry of the same task returns false.
+func (s *DynamoStore) PutInboxItem(ctx context.Context, item models.InboxItem) (bool, error) {
+	item.UserID = tenantKey(item.TenantID, item.UserID)
+	av, err := attributevalue.MarshalMap(item)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = s.db.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(s.inboxTable),
+		Item:                av,
+		ConditionExpression: aws.String("attribute_not_exists(item_id)"),
+	})
+	if err != nil {
+		var cfe *types.ConditionalCheckFailedException
+		if errors.As(err, &cfe) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// ListInbox returns newest-first items for userID. view is "all" (not archived),
+// "unread" or "archived". Filtering happens after the page is read, so a page
+// can hold fewer than limit items; keep following nextCursor until it is empty.
+func (s *DynamoStore) ListInbox(ctx context.Context, tenantID, userID, view string, limit int32, cursor string) ([]models.InboxItem, string, error) {
+	key := tenantKey(tenantID, userID)
+	in := &dynamodb.QueryInput{
+		TableName:              aws.String(s.inboxTable),
+		KeyConditionExpression: aws.String("user_id = :u"),
+		ScanIndexForward:       aws.Bool(false),
+		Limit:                  aws.Int32(limit),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":u": &types.AttributeValueMemberS{Value: key},
+		},
+	}
+
+	switch view {
+	case "unread":
+		in.FilterExpression = aws.String("#rd = :f AND archived = :f")
+		in.ExpressionAttributeNames = map[string]string{"#rd": "read"}
+		in.ExpressionAttributeValues[":f"] = &types.AttributeValueMemberBOOL{Value: false}
+	case "archived":
+		in.FilterExpression = aws.String("archived = :t")
+		in.ExpressionAttributeValues[":t"] = &types.AttributeValueMemberBOOL{Value: true}
+	default:
+		in.FilterExpression = aws.String("archived = :f")
+		in.ExpressionAttributeValues[":f"] = &types.AttributeValueMemberBOOL{Value: false}
+	}
+
+	if cursor != "" {
+		itemID, err := base64.RawURLEncoding.DecodeString(cursor)
+		if err != nil {
+			return nil, "", ErrInvalidCursor
+		}
+		in.ExclusiveStartKey = map[string]types.AttributeValue{
+			"user_id": &types.AttributeValueMemberS{Value: key},
+			"item_id": &types.AttributeValueMemberS{Value: string(itemID)},
+		}
+	}
+
+	out, err := s.db.Query(ctx, in)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var items []models.InboxItem
+	if err := attributevalue.UnmarshalListOfMaps(out.Items, &items); err != nil {
+		return nil, "", err
+	}
+	for i := range items {
+		items[i].TenantID, items[i].UserID = models.TenantOrDefault(tenantID), userID
+	}
+
+	next := ""
+	if last, ok := out.LastEvaluatedKey["item_id"].(*types.AttributeValueMemberS); ok {
+		next = base64.RawURLEncoding.EncodeToString([]byte(last.Value))
+	}
+	return items, next, nil
+}
+
+// CountUnread counts unread, unarchived items for userID.
+func (s *DynamoStore) CountUnread(ctx context.Context, tenantID, userID string) (int, error) {
+	p := dynamodb.NewQueryPaginator(s.db, &dynamodb.QueryInput{
+		TableName:              aws.String(s.inboxTable),
+		KeyConditionExpression: aws.String("user_id = :u"),
+		FilterExpression:       aws.String("#rd = :f AND archived = :f"),
+		Select:                 types.SelectCount,
+		ExpressionAttributeNames: map[string]string{
+			"#rd": "read",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":u": &types.AttributeValueMemberS{Value: tenantKey(tenantID, userID)},
+			":f": &types.AttributeValueMemberBOOL{Value: false},
+		},
+	})
+
+	total := 0
+	for p.HasMorePages() {
+		out, err := p.NextPage(ctx)
+		if err != nil {
+			return 0, err
+		}
+		total += int(out.Count)
+	}
+	return total, nil
+}
+
+// UpdateInboxItem sets read/archived flags and returns the item as it was
+// before, or nil if the item doesn't exist for this user.
+func (s *DynamoStore) UpdateInboxItem(ctx context.Context, tenantID, userID, itemID string, read, archived *bool, nowMs int64) (*models.InboxItem, error) {
+	expr := "SET updated_at = :u"
+	values := map[string]types.AttributeValue{
+		":u": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", nowMs)},
+	}
+	names := map[string]string{}
+	if read != nil {
+		expr += ", #rd = :rd, read_at = :ra"
+		names["#rd"] = "read"
+		values[":rd"] = &types.AttributeValueMemberBOOL{Value: *read}
+		readAt := int64(0)
+		if *read {
+			readAt = nowMs
+		}
+		values[":ra"] = &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", readAt)}
+	}
+	if archived != nil {
+		expr += ", archived = :ar"
+		values[":ar"] = &types.AttributeValueMemberBOOL{Value: *archived}
+	}
+
+	in := &dynamodb.UpdateItemInput{
+		TableName: aws.String(s.inboxTable),
+		Key: map[string]types.AttributeValue{
+			"user_id": &types.AttributeValueMemberS{Value: tenantKey(tenantID, userID)},
+			"item_id": &types.AttributeValueMemberS{Value: itemID},
+		},
+		ConditionExpression:       aws.String("attribute_exists(item_id)"),
+		UpdateExpression:          aws.String(expr),
+		ExpressionAttributeValues: values,
+		ReturnValues:              types.ReturnValueAllOld,
+	}
+	if len(names) > 0 {
+		in.ExpressionAttributeNames = names
+	}
+
+	out, err := s.db.UpdateItem(ctx, in)
+	if err != nil {
+		var cfe *types.ConditionalCheckFailedException
+		if errors.As(err, &cfe) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var before models.InboxItem
+	if err := attributevalue.UnmarshalMap(out.Attributes, &before); err != nil {
+		return nil, err
+	}
+	before.TenantID, before.UserID = models.TenantOrDefault(tenantID), userID
+	return &before, nil
+}
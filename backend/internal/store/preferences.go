@@ -0,0 +1,94 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"safe-notify/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func (s *DynamoStore) GetPreference(ctx context.Context, tenantID, recipient string) (*models.Preference, error) {
+	out, err := s.db.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(s.prefsTable),
+		Key: map[string]types.AttributeValue{
+			"recipient": &types.AttributeValueMemberS{Value: tenantKey(tenantID, recipient)},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if out.Item == nil {
+		return nil, nil
+	}
+
+	var p models.Preference
+	if err := attributevalue.UnmarshalMap(out.Item, &p); err != nil {
+		return nil, err
+	}
+	p.TenantID = models.TenantOrDefault(tenantID)
+	p.Recipient = recipient
+	return &p, nil
+}
+
+func (s *DynamoStore) PutPreference(ctx context.Context, p models.Preference) error {
+	p.Recipient = tenantKey(p.TenantID, p.Recipient)
+	item, err := attributevalue.MarshalMap(p)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.prefsTable),
+		Item:      item,
+	})
+	return err
+}
+
+// SetSubscription flips a single "<event_type>:<channel>" entry without
+// clobbering the rest of the recipient's preferences.
+func (s *DynamoStore) SetSubscription(ctx context.Context, tenantID, recipient, key string, allowed bool, nowMs int64) error {
+	recipient = tenantKey(tenantID, recipient)
+	_, err := s.db.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(s.prefsTable),
+		Key: map[string]types.AttributeValue{
+			"recipient": &types.AttributeValueMemberS{Value: recipient},
+		},
+		ConditionExpression: aws.String("attribute_exists(subscriptions)"),
+		UpdateExpression:    aws.String("SET subscriptions.#k = :v, updated_at = :u"),
+		ExpressionAttributeNames: map[string]string{
+			"#k": key,
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":v": &types.AttributeValueMemberBOOL{Value: allowed},
+			":u": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", nowMs)},
+		},
+	})
+	var cfe *types.ConditionalCheckFailedException
+	if !errors.As(err, &cfe) {
+		return err
+	}
+
+	// No record (or no map) yet: create the map with just this entry
+	_, err = s.db.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(s.prefsTable),
+		Key: map[string]types.AttributeValue{
+			"recipient": &types.AttributeValueMemberS{Value: recipient},
+		},
+		ConditionExpression: aws.String("attribute_not_exists(subscriptions)"),
+		UpdateExpression:    aws.String("SET subscriptions = :m, tenant_id = :t, updated_at = :u"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":t": &types.AttributeValueMemberS{Value: models.TenantOrDefault(tenantID)},
+			":m": &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+				key: &types.AttributeValueMemberBOOL{Value: allowed},
+			}},
+			":u": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", nowMs)},
+		},
+	})
+	return err
+}
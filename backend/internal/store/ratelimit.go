@@ -0,0 +1,144 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// The rate limit table is keyed by bucket_key; expires_at is meant to be
+// the table's TTL attribute (an idle bucket is full anyway). version goes
+// up by one on every write and is what concurrent writers compare-and-set
+// on; updated_at can repeat within a millisecond or go back between hosts.
+
+type tokenBucket struct {
+	BucketKey string  `dynamodbav:"bucket_key"`
+	Tokens    float64 `dynamodbav:"tokens"`
+	UpdatedAt int64   `dynamodbav:"updated_at"`
+	Version   int64   `dynamodbav:"version"`
+	ExpiresAt int64   `dynamodbav:"expires_at"`
+}
+
+// TakeToken takes one token from the bucket key, which refills at rate
+// tokens per second up to burst. It returns 0 if a token was taken, or how
+// long to wait before one is available. Concurrent workers are serialised
+// with a compare-and-set on the bucket's version.
+func (s *DynamoStore) TakeToken(ctx context.Context, key string, rate float64, burst int, nowMs int64) (int64, error) {
+	for i := 0; i < 5; i++ {
+		out, err := s.db.GetItem(ctx, &dynamodb.GetItemInput{
+			TableName:      aws.String(s.rateLimitTable),
+			ConsistentRead: aws.Bool(true),
+			Key: map[string]types.AttributeValue{
+				"bucket_key": &types.AttributeValueMemberS{Value: key},
+			},
+		})
+		if err != nil {
+			return 0, err
+		}
+
+		b := tokenBucket{BucketKey: key, Tokens: float64(burst)}
+		cond := "attribute_not_exists(bucket_key)"
+		values := map[string]types.AttributeValue{}
+		if out.Item != nil {
+			if err := attributevalue.UnmarshalMap(out.Item, &b); err != nil {
+				return 0, err
+			}
+			elapsed := float64(nowMs-b.UpdatedAt) / 1000
+			if elapsed > 0 {
+				b.Tokens = math.Min(float64(burst), b.Tokens+elapsed*rate)
+			}
+			cond = "version = :old"
+			if b.Version == 0 {
+				cond = "attribute_not_exists(version)" // written before buckets were versioned
+			} else {
+				values[":old"] = &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", b.Version)}
+			}
+		}
+
+		if b.Tokens < 1 {
+			return int64(math.Ceil((1 - b.Tokens) / rate * 1000)), nil
+		}
+
+		b.Tokens--
+		b.UpdatedAt = max(b.UpdatedAt, nowMs) // a host with a slow clock must not refill it twice
+		b.Version++
+		b.ExpiresAt = (nowMs+int64(float64(burst)/rate*1000))/1000 + 60 // TTL is epoch seconds
+		item, err := attributevalue.MarshalMap(b)
+		if err != nil {
+			return 0, err
+		}
+		in := &dynamodb.PutItemInput{
+			TableName:           aws.String(s.rateLimitTable),
+			Item:                item,
+			ConditionExpression: aws.String(cond),
+		}
+		if len(values) > 0 {
+			in.ExpressionAttributeValues = values
+		}
+
+		_, err = s.db.PutItem(ctx, in)
+		if err == nil {
+			return 0, nil
+		}
+		var cfe *types.ConditionalCheckFailedException
+		if !errors.As(err, &cfe) {
+			return 0, err
+		}
+		// Another worker took a token in between; re-read and try again
+	}
+
+	// Heavy contention: the bucket is busy, so treat it as briefly empty
+	return int64(math.Ceil(1000 / rate)), nil
+}
+
+// ReturnToken gives back a token TakeToken took from the bucket key, e.g.
+// when a wider bucket then refused the same send. A bucket that has
+// refilled to burst, or expired, is left as it is.
+func (s *DynamoStore) ReturnToken(ctx context.Context, key string, burst int) error {
+	_, err := s.db.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(s.rateLimitTable),
+		Key: map[string]types.AttributeValue{
+			"bucket_key": &types.AttributeValueMemberS{Value: key},
+		},
+		ConditionExpression: aws.String("attribute_exists(bucket_key) AND tokens <= :max"),
+		UpdateExpression:    aws.String("ADD tokens :one, version :one"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":one": &types.AttributeValueMemberN{Value: "1"},
+			":max": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", burst-1)},
+		},
+	})
+	var cfe *types.ConditionalCheckFailedException
+	if errors.As(err, &cfe) {
+		return nil
+	}
+	return err
+}
+
+// Incr counts one request in the fixed-window counter key, shared by every
+// API replica, and returns the new count. Counters live in the rate limit
+// table next to the token buckets, prefixed "req|".
+func (s *DynamoStore) Incr(ctx context.Context, key string, expiresAtMs int64) (int64, error) {
+	out, err := s.db.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(s.rateLimitTable),
+		Key: map[string]types.AttributeValue{
+			"bucket_key": &types.AttributeValueMemberS{Value: "req|" + key},
+		},
+		UpdateExpression: aws.String("ADD hits :one SET expires_at = :exp"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":one": &types.AttributeValueMemberN{Value: "1"},
+			":exp": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", expiresAtMs/1000+60)}, // TTL is epoch seconds
+		},
+		ReturnValues: types.ReturnValueUpdatedNew,
+	})
+	if err != nil {
+		return 0, err
+	}
+	n, _ := numberAttr(out.Attributes["hits"])
+	return n, nil
+}
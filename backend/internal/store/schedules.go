@@ -0,0 +1,164 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"safe-notify/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// The schedules table is keyed by schedule_id.
+
+func (s *DynamoStore) PutSchedule(ctx context.Context, sch models.Schedule) error {
+	item, err := attributevalue.MarshalMap(sch)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.schedulesTable),
+		Item:      item,
+	})
+	return err
+}
+
+func (s *DynamoStore) GetSchedule(ctx context.Context, scheduleID string) (*models.Schedule, error) {
+	out, err := s.db.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(s.schedulesTable),
+		Key: map[string]types.AttributeValue{
+			"schedule_id": &types.AttributeValueMemberS{Value: scheduleID},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if out.Item == nil {
+		return nil, nil
+	}
+
+	var sch models.Schedule
+	if err := attributevalue.UnmarshalMap(out.Item, &sch); err != nil {
+		return nil, err
+	}
+	return &sch, nil
+}
+
+func (s *DynamoStore) ListSchedules(ctx context.Context, tenantID string) ([]models.Schedule, error) {
+	values := map[string]types.AttributeValue{}
+	p := dynamodb.NewScanPaginator(s.db, &dynamodb.ScanInput{
+		TableName:                 aws.String(s.schedulesTable),
+		FilterExpression:          aws.String(tenantCond(tenantID, values)),
+		ExpressionAttributeValues: values,
+	})
+
+	var out []models.Schedule
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var items []models.Schedule
+		if err := attributevalue.UnmarshalListOfMaps(page.Items, &items); err != nil {
+			return nil, err
+		}
+		out = append(out, items...)
+	}
+	return out, nil
+}
+
+func (s *DynamoStore) DeleteSchedule(ctx context.Context, scheduleID string) error {
+	_, err := s.db.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(s.schedulesTable),
+		Key: map[string]types.AttributeValue{
+			"schedule_id": &types.AttributeValueMemberS{Value: scheduleID},
+		},
+	})
+	return err
+}
+
+// FetchDueSchedules returns unpaused schedules whose next occurrence has passed.
+func (s *DynamoStore) FetchDueSchedules(ctx context.Context, nowMs int64) ([]models.Schedule, error) {
+	p := dynamodb.NewScanPaginator(s.db, &dynamodb.ScanInput{
+		TableName:        aws.String(s.schedulesTable),
+		FilterExpression: aws.String("paused = :f AND next_run_at > :zero AND next_run_at <= :now"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":f":    &types.AttributeValueMemberBOOL{Value: false},
+			":zero": &types.AttributeValueMemberN{Value: "0"},
+			":now":  &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", nowMs)},
+		},
+	})
+
+	var out []models.Schedule
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var items []models.Schedule
+		if err := attributevalue.UnmarshalListOfMaps(page.Items, &items); err != nil {
+			return nil, err
+		}
+		out = append(out, items...)
+	}
+	return out, nil
+}
+
+// AdvanceSchedule moves next_run_at from fromMs to toMs after an occurrence
+// has been materialised. It returns false if another scheduler (or a
+// pause/resume) already changed next_run_at.
+func (s *DynamoStore) AdvanceSchedule(ctx context.Context, scheduleID string, fromMs, toMs, nowMs int64) (bool, error) {
+	_, err := s.db.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(s.schedulesTable),
+		Key: map[string]types.AttributeValue{
+			"schedule_id": &types.AttributeValueMemberS{Value: scheduleID},
+		},
+		ConditionExpression: aws.String("next_run_at = :from"),
+		UpdateExpression:    aws.String("SET next_run_at = :to, last_run_at = :from, updated_at = :u"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":from": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", fromMs)},
+			":to":   &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", toMs)},
+			":u":    &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", nowMs)},
+		},
+	})
+	if err != nil {
+		var cfe *types.ConditionalCheckFailedException
+		if errors.As(err, &cfe) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// SetSchedulePaused pauses or resumes a schedule. Resuming sets next_run_at
+// so occurrences missed while paused are skipped. It returns false if the
+// schedule doesn't exist.
+func (s *DynamoStore) SetSchedulePaused(ctx context.Context, scheduleID string, paused bool, nextRunAt, nowMs int64) (bool, error) {
+	_, err := s.db.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(s.schedulesTable),
+		Key: map[string]types.AttributeValue{
+			"schedule_id": &types.AttributeValueMemberS{Value: scheduleID},
+		},
+		ConditionExpression: aws.String("attribute_exists(schedule_id)"),
+		UpdateExpression:    aws.String("SET paused = :p, next_run_at = :n, updated_at = :u"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":p": &types.AttributeValueMemberBOOL{Value: paused},
+			":n": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", nextRunAt)},
+			":u": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", nowMs)},
+		},
+	})
+	if err != nil {
+		var cfe *types.ConditionalCheckFailedException
+		if errors.As(err, &cfe) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
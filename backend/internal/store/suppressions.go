@@ -0,0 +1,72 @@
+package store
+
+import (
+	"context"
+
+	"safe-notify/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func (s *DynamoStore) GetSuppression(ctx context.Context, address string) (*models.Suppression, error) {
+	out, err := s.db.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(s.suppTable),
+		Key: map[string]types.AttributeValue{
+			"address": &types.AttributeValueMemberS{Value: address},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if out.Item == nil {
+		return nil, nil
+	}
+
+	var sup models.Suppression
+	if err := attributevalue.UnmarshalMap(out.Item, &sup); err != nil {
+		return nil, err
+	}
+	return &sup, nil
+}
+
+func (s *DynamoStore) PutSuppression(ctx context.Context, sup models.Suppression) error {
+	item, err := attributevalue.MarshalMap(sup)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.suppTable),
+		Item:      item,
+	})
+	return err
+}
+
+func (s *DynamoStore) DeleteSuppression(ctx context.Context, address string) error {
+	_, err := s.db.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(s.suppTable),
+		Key: map[string]types.AttributeValue{
+			"address": &types.AttributeValueMemberS{Value: address},
+		},
+	})
+	return err
+}
+
+func (s *DynamoStore) ListSuppressions(ctx context.Context, limit int32) ([]models.Suppression, error) {
+	out, err := s.db.Scan(ctx, &dynamodb.ScanInput{
+		TableName: aws.String(s.suppTable),
+		Limit:     aws.Int32(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var sups []models.Suppression
+	if err := attributevalue.UnmarshalListOfMaps(out.Items, &sups); err != nil {
+		return nil, err
+	}
+	return sups, nil
+}
@@ -0,0 +1,31 @@
+package store
+
+import (
+	"safe-notify/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Tables keyed by recipient or user ID (preferences, devices, inbox) are
+// partitioned by tenant: the stored key is "<tenant>#<id>", so the same user
+// ID in two tenants never shares a record. The default tenant keeps bare keys,
+// which leaves records written before tenants existed where they were.
+
+func tenantKey(tenantID, id string) string {
+	tenantID = models.TenantOrDefault(tenantID)
+	if tenantID == models.DefaultTenant {
+		return id
+	}
+	return tenantID + "#" + id
+}
+
+// tenantCond is a filter matching items owned by tenantID. Untagged items
+// belong to the default tenant.
+func tenantCond(tenantID string, values map[string]types.AttributeValue) string {
+	tenantID = models.TenantOrDefault(tenantID)
+	values[":tenant"] = &types.AttributeValueMemberS{Value: tenantID}
+	if tenantID == models.DefaultTenant {
+		return "(attribute_not_exists(tenant_id) OR tenant_id = :tenant)"
+	}
+	return "tenant_id = :tenant"
+}
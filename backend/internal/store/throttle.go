@@ -0,0 +1,136 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// The throttle table is keyed by throttle_key (rule scope); expires_at is
+// meant to be the table's TTL attribute. Each item keeps the send times
+// still inside the window, so the window slides instead of resetting.
+type throttleLog struct {
+	ThrottleKey string  `dynamodbav:"throttle_key"`
+	SentAt      []int64 `dynamodbav:"sent_at"` // epoch ms, oldest first
+	Version     int64   `dynamodbav:"version"` // compare-and-swap guard
+	ExpiresAt   int64   `dynamodbav:"expires_at"`
+}
+
+var errThrottleBusy = errors.New("throttle: too much contention on key")
+
+// AcquireThrottle atomically records a send at nowMs against key and
+// returns false, without recording it, once max sends were recorded in the
+// windowMs before nowMs.
+func (s *DynamoStore) AcquireThrottle(ctx context.Context, key string, max int, windowMs, nowMs int64) (bool, error) {
+	for i := 0; i < 5; i++ {
+		l, cond, values, err := s.getThrottleLog(ctx, key)
+		if err != nil {
+			return false, err
+		}
+
+		kept := l.SentAt[:0]
+		for _, at := range l.SentAt {
+			if at > nowMs-windowMs {
+				kept = append(kept, at)
+			}
+		}
+		if len(kept) >= max {
+			return false, nil
+		}
+		l.SentAt = append(kept, nowMs)
+
+		l.ExpiresAt = (nowMs+windowMs)/1000 + 60 // TTL is epoch seconds
+		ok, err := s.putThrottleLog(ctx, l, cond, values)
+		if err != nil || ok {
+			return ok, err
+		}
+		// Another request recorded a send in between; re-read and try again
+	}
+	return false, errThrottleBusy
+}
+
+// ReleaseThrottle removes the send AcquireThrottle recorded at sentAtMs,
+// for a task that was not stored after all. A send that already left the
+// window, or expired, is not there to remove.
+func (s *DynamoStore) ReleaseThrottle(ctx context.Context, key string, sentAtMs int64) error {
+	for i := 0; i < 5; i++ {
+		l, cond, values, err := s.getThrottleLog(ctx, key)
+		if err != nil {
+			return err
+		}
+		idx := -1
+		for j, at := range l.SentAt {
+			if at == sentAtMs {
+				idx = j
+				break
+			}
+		}
+		if idx < 0 {
+			return nil
+		}
+		l.SentAt = append(l.SentAt[:idx], l.SentAt[idx+1:]...)
+
+		ok, err := s.putThrottleLog(ctx, l, cond, values)
+		if err != nil || ok {
+			return err
+		}
+	}
+	return errThrottleBusy
+}
+
+// getThrottleLog reads key's log along with the condition a write of it
+// must meet: that nobody else wrote it since.
+func (s *DynamoStore) getThrottleLog(ctx context.Context, key string) (throttleLog, string, map[string]types.AttributeValue, error) {
+	l := throttleLog{ThrottleKey: key}
+	out, err := s.db.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(s.throttleTable),
+		ConsistentRead: aws.Bool(true),
+		Key: map[string]types.AttributeValue{
+			"throttle_key": &types.AttributeValueMemberS{Value: key},
+		},
+	})
+	if err != nil {
+		return l, "", nil, err
+	}
+	if out.Item == nil {
+		return l, "attribute_not_exists(throttle_key)", nil, nil
+	}
+	if err := attributevalue.UnmarshalMap(out.Item, &l); err != nil {
+		return l, "", nil, err
+	}
+	return l, "version = :old", map[string]types.AttributeValue{
+		":old": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", l.Version)},
+	}, nil
+}
+
+// putThrottleLog writes l with the next version if cond still holds, and
+// returns false if it doesn't.
+func (s *DynamoStore) putThrottleLog(ctx context.Context, l throttleLog, cond string, values map[string]types.AttributeValue) (bool, error) {
+	l.Version++
+	item, err := attributevalue.MarshalMap(l)
+	if err != nil {
+		return false, err
+	}
+	in := &dynamodb.PutItemInput{
+		TableName:           aws.String(s.throttleTable),
+		Item:                item,
+		ConditionExpression: aws.String(cond),
+	}
+	if len(values) > 0 {
+		in.ExpressionAttributeValues = values
+	}
+	_, err = s.db.PutItem(ctx, in)
+	if err != nil {
+		var cfe *types.ConditionalCheckFailedException
+		if errors.As(err, &cfe) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,167 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+
+	"safe-notify/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// The usage table is keyed by usage_key ("<tenant>|<period>"). Each item
+// holds one numeric attribute per counter: "accepted" plus
+// "c:<CHANNEL>:<STATUS>" for delivery outcomes. All updates are ADDs, so
+// concurrent workers never lose a count.
+
+func usageKey(tenantID, period string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"usage_key": &types.AttributeValueMemberS{Value: models.TenantOrDefault(tenantID) + "|" + period},
+	}
+}
+
+// ReserveQuota counts n accepted tasks against period. With hard > 0 it
+// refuses, without counting, if that would take the total past hard. It
+// returns the total after counting (or the current total when refused).
+func (s *DynamoStore) ReserveQuota(ctx context.Context, tenantID, period string, n, hard int) (int64, bool, error) {
+	if hard > 0 && n > hard {
+		// Can never fit; the condition below would let it into an empty period
+		used, err := s.usageCounter(ctx, tenantID, period, "accepted")
+		return used, false, err
+	}
+	in := &dynamodb.UpdateItemInput{
+		TableName:        aws.String(s.usageTable),
+		Key:              usageKey(tenantID, period),
+		UpdateExpression: aws.String("ADD accepted :n SET tenant_id = :t, period = :p"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":n": &types.AttributeValueMemberN{Value: strconv.Itoa(n)},
+			":t": &types.AttributeValueMemberS{Value: models.TenantOrDefault(tenantID)},
+			":p": &types.AttributeValueMemberS{Value: period},
+		},
+		ReturnValues: types.ReturnValueUpdatedNew,
+	}
+	if hard > 0 {
+		in.ConditionExpression = aws.String("attribute_not_exists(accepted) OR accepted <= :room")
+		in.ExpressionAttributeValues[":room"] = &types.AttributeValueMemberN{Value: strconv.Itoa(hard - n)}
+	}
+
+	out, err := s.db.UpdateItem(ctx, in)
+	if err != nil {
+		var cfe *types.ConditionalCheckFailedException
+		if errors.As(err, &cfe) {
+			used, err := s.usageCounter(ctx, tenantID, period, "accepted")
+			return used, false, err
+		}
+		return 0, false, err
+	}
+	used, _ := numberAttr(out.Attributes["accepted"])
+	return used, true, nil
+}
+
+// ReleaseQuota gives back n tasks reserved by ReserveQuota, e.g. when the
+// monthly quota refused a request the daily one had already counted.
+func (s *DynamoStore) ReleaseQuota(ctx context.Context, tenantID, period string, n int) error {
+	_, err := s.db.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:        aws.String(s.usageTable),
+		Key:              usageKey(tenantID, period),
+		UpdateExpression: aws.String("ADD accepted :n"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":n": &types.AttributeValueMemberN{Value: strconv.Itoa(-n)},
+		},
+	})
+	return err
+}
+
+// RecordUsage counts one delivery outcome in each of periods (day and month).
+func (s *DynamoStore) RecordUsage(ctx context.Context, tenantID, channel, status string, periods ...string) error {
+	for _, period := range periods {
+		_, err := s.db.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+			TableName:        aws.String(s.usageTable),
+			Key:              usageKey(tenantID, period),
+			UpdateExpression: aws.String("ADD #c :one SET tenant_id = :t, period = :p"),
+			ExpressionAttributeNames: map[string]string{
+				"#c": "c:" + channel + ":" + status,
+			},
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":one": &types.AttributeValueMemberN{Value: "1"},
+				":t":   &types.AttributeValueMemberS{Value: models.TenantOrDefault(tenantID)},
+				":p":   &types.AttributeValueMemberS{Value: period},
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetUsage returns every counter of one period, ACCEPTED first, then by
+// channel and status. A period with no activity has no rows.
+func (s *DynamoStore) GetUsage(ctx context.Context, tenantID, period string) ([]models.UsageRow, error) {
+	out, err := s.db.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(s.usageTable),
+		Key:       usageKey(tenantID, period),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []models.UsageRow
+	for name, av := range out.Item {
+		n, ok := numberAttr(av)
+		if !ok {
+			continue
+		}
+		switch {
+		case name == "accepted":
+			rows = append(rows, models.UsageRow{Period: period, Channel: "*", Status: models.UsageStatusAccepted, Count: n})
+		case strings.HasPrefix(name, "c:"):
+			ch, st, _ := strings.Cut(strings.TrimPrefix(name, "c:"), ":")
+			rows = append(rows, models.UsageRow{Period: period, Channel: ch, Status: st, Count: n})
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if (rows[i].Status == models.UsageStatusAccepted) != (rows[j].Status == models.UsageStatusAccepted) {
+			return rows[i].Status == models.UsageStatusAccepted
+		}
+		if rows[i].Channel != rows[j].Channel {
+			return rows[i].Channel < rows[j].Channel
+		}
+		return rows[i].Status < rows[j].Status
+	})
+	return rows, nil
+}
+
+func (s *DynamoStore) usageCounter(ctx context.Context, tenantID, period, attr string) (int64, error) {
+	out, err := s.db.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:            aws.String(s.usageTable),
+		Key:                  usageKey(tenantID, period),
+		ConsistentRead:       aws.Bool(true),
+		ProjectionExpression: aws.String("#a"),
+		ExpressionAttributeNames: map[string]string{
+			"#a": attr,
+		},
+	})
+	if err != nil {
+		return 0, err
+	}
+	n, _ := numberAttr(out.Item[attr])
+	return n, nil
+}
+
+func numberAttr(av types.AttributeValue) (int64, bool) {
+	n, ok := av.(*types.AttributeValueMemberN)
+	if !ok {
+		return 0, false
+	}
+	v, err := strconv.ParseInt(n.Value, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return v, true
+}
@@ -0,0 +1,299 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"safe-notify/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func (s *DynamoStore) PutWebhookSubscription(ctx context.Context, sub models.WebhookSubscription) error {
+	item, err := attributevalue.MarshalMap(sub)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.hooksTable),
+		Item:      item,
+	})
+	return err
+}
+
+// ListWebhookSubscriptions returns every subscription of tenantID; the table is small.
+func (s *DynamoStore) ListWebhookSubscriptions(ctx context.Context, tenantID string) ([]models.WebhookSubscription, error) {
+	var subs []models.WebhookSubscription
+	values := map[string]types.AttributeValue{}
+	p := dynamodb.NewScanPaginator(s.db, &dynamodb.ScanInput{
+		TableName:                 aws.String(s.hooksTable),
+		FilterExpression:          aws.String(tenantCond(tenantID, values)),
+		ExpressionAttributeValues: values,
+	})
+	for p.HasMorePages() {
+		out, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var page []models.WebhookSubscription
+		if err := attributevalue.UnmarshalListOfMaps(out.Items, &page); err != nil {
+			return nil, err
+		}
+		subs = append(subs, page...)
+	}
+	return subs, nil
+}
+
+func (s *DynamoStore) GetWebhookSubscription(ctx context.Context, subscriptionID string) (*models.WebhookSubscription, error) {
+	out, err := s.db.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(s.hooksTable),
+		Key: map[string]types.AttributeValue{
+			"subscription_id": &types.AttributeValueMemberS{Value: subscriptionID},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if out.Item == nil {
+		return nil, nil
+	}
+
+	var sub models.WebhookSubscription
+	if err := attributevalue.UnmarshalMap(out.Item, &sub); err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+func (s *DynamoStore) DeleteWebhookSubscription(ctx context.Context, subscriptionID string) error {
+	_, err := s.db.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(s.hooksTable),
+		Key: map[string]types.AttributeValue{
+			"subscription_id": &types.AttributeValueMemberS{Value: subscriptionID},
+		},
+	})
+	return err
+}
+
+// CreateWebhookDelivery stores d unless a delivery with the same ID exists.
+// It returns false when it already existed (e.g. a redelivered Kafka message).
+func (s *DynamoStore) CreateWebhookDelivery(ctx context.Context, d models.WebhookDelivery) (bool, error) {
+	item, err := attributevalue.MarshalMap(d)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = s.db.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(s.hookDlvTable),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(delivery_id)"),
+	})
+	if err != nil {
+		var cfe *types.ConditionalCheckFailedException
+		if errors.As(err, &cfe) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *DynamoStore) GetWebhookDelivery(ctx context.Context, deliveryID string) (*models.WebhookDelivery, error) {
+	out, err := s.db.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(s.hookDlvTable),
+		Key: map[string]types.AttributeValue{
+			"delivery_id": &types.AttributeValueMemberS{Value: deliveryID},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if out.Item == nil {
+		return nil, nil
+	}
+
+	var d models.WebhookDelivery
+	if err := attributevalue.UnmarshalMap(out.Item, &d); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (s *DynamoStore) ClaimWebhookDelivery(ctx context.Context, deliveryID string, nowMs int64) (bool, error) {
+	_, err := s.db.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(s.hookDlvTable),
+		Key: map[string]types.AttributeValue{
+			"delivery_id": &types.AttributeValueMemberS{Value: deliveryID},
+		},
+		ConditionExpression: aws.String("#st = :pending OR #st = :failed"),
+		UpdateExpression:    aws.String("SET #st = :processing, updated_at = :u"),
+		ExpressionAttributeNames: map[string]string{
+			"#st": "status",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pending":    &types.AttributeValueMemberS{Value: "PENDING"},
+			":failed":     &types.AttributeValueMemberS{Value: "FAILED"},
+			":processing": &types.AttributeValueMemberS{Value: "PROCESSING"},
+			":u":          &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", nowMs)},
+		},
+	})
+	if err != nil {
+		var cfe *types.ConditionalCheckFailedException
+		if errors.As(err, &cfe) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *DynamoStore) UpdateWebhookDelivery(
+	ctx context.Context,
+	deliveryID string,
+	newStatus string,
+	attemptCount int,
+	lastError string,
+	nextRetryAt int64,
+	nowMs int64,
+) error {
+	_, err := s.db.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(s.hookDlvTable),
+		Key: map[string]types.AttributeValue{
+			"delivery_id": &types.AttributeValueMemberS{Value: deliveryID},
+		},
+		UpdateExpression: aws.String("SET #st = :st, attempt_count = :ac, last_error = :le, next_retry_at = :nra, updated_at = :u"),
+		ExpressionAttributeNames: map[string]string{
+			"#st": "status",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":st":  &types.AttributeValueMemberS{Value: newStatus},
+			":ac":  &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", attemptCount)},
+			":le":  &types.AttributeValueMemberS{Value: lastError},
+			":nra": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", nextRetryAt)},
+			":u":   &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", nowMs)},
+		},
+	})
+	return err
+}
+
+// ReleaseWebhookDelivery hands a claimed delivery back after an error
+// part-way through an attempt: it goes back to FAILED with no retry time,
+// so the redelivered Kafka message (or the scheduler) can claim it again.
+// It returns false if the delivery is no longer PROCESSING.
+func (s *DynamoStore) ReleaseWebhookDelivery(ctx context.Context, deliveryID, reason string, nowMs int64) (bool, error) {
+	_, err := s.db.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(s.hookDlvTable),
+		Key: map[string]types.AttributeValue{
+			"delivery_id": &types.AttributeValueMemberS{Value: deliveryID},
+		},
+		ConditionExpression: aws.String("#st = :processing"),
+		UpdateExpression:    aws.String("SET #st = :failed, next_retry_at = :zero, last_error = :le, updated_at = :u"),
+		ExpressionAttributeNames: map[string]string{
+			"#st": "status",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":processing": &types.AttributeValueMemberS{Value: "PROCESSING"},
+			":failed":     &types.AttributeValueMemberS{Value: "FAILED"},
+			":zero":       &types.AttributeValueMemberN{Value: "0"},
+			":le":         &types.AttributeValueMemberS{Value: reason},
+			":u":          &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", nowMs)},
+		},
+	})
+	if err != nil {
+		var cfe *types.ConditionalCheckFailedException
+		if errors.As(err, &cfe) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// FetchStalledWebhookDeliveries returns deliveries nothing is going to pick
+// up on its own:
+//   - PENDING and untouched since beforeMs: stored by a worker that died
+//     before publishing them, or whose Kafka message was lost
+//   - PROCESSING since claimedBeforeMs: the worker died mid-attempt
+//   - FAILED with a retry time (or its last update) before beforeMs: the
+//     retry message was never published, or was lost
+func (s *DynamoStore) FetchStalledWebhookDeliveries(ctx context.Context, beforeMs, claimedBeforeMs int64, limit int32) ([]models.WebhookDelivery, error) {
+	p := dynamodb.NewScanPaginator(s.db, &dynamodb.ScanInput{
+		TableName: aws.String(s.hookDlvTable),
+		FilterExpression: aws.String(
+			"(#st = :pending AND updated_at < :before) OR " +
+				"(#st = :processing AND updated_at < :claimed) OR " +
+				"(#st = :failed AND next_retry_at < :before AND updated_at < :before)",
+		),
+		ExpressionAttributeNames: map[string]string{
+			"#st": "status",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pending":    &types.AttributeValueMemberS{Value: "PENDING"},
+			":processing": &types.AttributeValueMemberS{Value: "PROCESSING"},
+			":failed":     &types.AttributeValueMemberS{Value: "FAILED"},
+			":before":     &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", beforeMs)},
+			":claimed":    &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", claimedBeforeMs)},
+		},
+	})
+
+	var out []models.WebhookDelivery
+	for p.HasMorePages() && len(out) < int(limit) {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var items []models.WebhookDelivery
+		if err := attributevalue.UnmarshalListOfMaps(page.Items, &items); err != nil {
+			return nil, err
+		}
+		out = append(out, items...)
+	}
+	if len(out) > int(limit) {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+// TouchWebhookDelivery moves a stalled delivery's updated_at from d's to
+// nowMs. A PROCESSING delivery is also handed back as FAILED with no retry
+// time, so the republished message can claim it. It returns false if the
+// delivery moved on, or another scheduler touched it first.
+func (s *DynamoStore) TouchWebhookDelivery(ctx context.Context, d models.WebhookDelivery, nowMs int64) (bool, error) {
+	update := "SET updated_at = :u"
+	values := map[string]types.AttributeValue{
+		":st":  &types.AttributeValueMemberS{Value: d.Status},
+		":old": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", d.UpdatedAt)},
+		":u":   &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", nowMs)},
+	}
+	if d.Status == "PROCESSING" {
+		update = "SET #st = :failed, next_retry_at = :zero, updated_at = :u"
+		values[":failed"] = &types.AttributeValueMemberS{Value: "FAILED"}
+		values[":zero"] = &types.AttributeValueMemberN{Value: "0"}
+	}
+
+	_, err := s.db.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(s.hookDlvTable),
+		Key: map[string]types.AttributeValue{
+			"delivery_id": &types.AttributeValueMemberS{Value: d.DeliveryID},
+		},
+		ConditionExpression: aws.String("#st = :st AND updated_at = :old"),
+		UpdateExpression:    aws.String(update),
+		ExpressionAttributeNames: map[string]string{
+			"#st": "status",
+		},
+		ExpressionAttributeValues: values,
+	})
+	if err != nil {
+		var cfe *types.ConditionalCheckFailedException
+		if errors.As(err, &cfe) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,80 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"safe-notify/internal/models"
+)
+
+// QuotaStore keeps the accepted-task counter of each tenant and period.
+type QuotaStore interface {
+	ReserveQuota(ctx context.Context, tenantID, period string, n, hard int) (int64, bool, error)
+	ReleaseQuota(ctx context.Context, tenantID, period string, n int) error
+}
+
+// QuotaCheck is the outcome of counting new tasks against a tenant's
+// quotas. Exceeded means a hard quota refused them; ResetAt is when that
+// quota's period ends. Warning is set when a soft quota has been passed.
+type QuotaCheck struct {
+	Exceeded bool
+	Reason   string
+	ResetAt  time.Time
+	Warning  string
+}
+
+// ReserveQuota counts n new tasks against c's daily and monthly quotas. The
+// counts are taken atomically in the store, so concurrent callers can't both
+// slip under a hard limit. Tasks refused by the monthly quota give back
+// their daily reservation. Once the tasks are accepted, nothing is given
+// back; call ReleaseQuota with the same now for tasks that were not created.
+func (c Config) ReserveQuota(ctx context.Context, st QuotaStore, tenantID string, n int, now time.Time) (QuotaCheck, error) {
+	limits := []struct {
+		name   string
+		quota  Quota
+		period string
+		reset  time.Time
+	}{
+		{"daily", c.DailyQuota, models.UsageDay(now), models.NextUsageDay(now)},
+		{"monthly", c.MonthlyQuota, models.UsageMonth(now), models.NextUsageMonth(now)},
+	}
+
+	var check QuotaCheck
+	for i, l := range limits {
+		used, ok, err := st.ReserveQuota(ctx, tenantID, l.period, n, l.quota.Hard)
+		if err == nil && !ok {
+			for _, prev := range limits[:i] {
+				err = st.ReleaseQuota(ctx, tenantID, prev.period, n)
+				if err != nil {
+					break
+				}
+			}
+			check.Exceeded = true
+			check.Reason = fmt.Sprintf("%s quota exceeded: %d of %d tasks used", l.name, used, l.quota.Hard)
+			if n > l.quota.Hard {
+				check.Reason = fmt.Sprintf("%s quota exceeded: %d tasks is more than the quota of %d", l.name, n, l.quota.Hard)
+			}
+			check.ResetAt = l.reset
+		}
+		if err != nil || check.Exceeded {
+			return check, err
+		}
+		if l.quota.Soft > 0 && used > int64(l.quota.Soft) && check.Warning == "" {
+			check.Warning = fmt.Sprintf("%s soft quota passed: %d of %d tasks used", l.name, used, l.quota.Soft)
+		}
+	}
+	return check, nil
+}
+
+// ReleaseQuota gives back n tasks that ReserveQuota accepted at now.
+func ReleaseQuota(ctx context.Context, st QuotaStore, tenantID string, n int, now time.Time) error {
+	if n <= 0 {
+		return nil
+	}
+	return errors.Join(
+		st.ReleaseQuota(ctx, tenantID, models.UsageDay(now), n),
+		st.ReleaseQuota(ctx, tenantID, models.UsageMonth(now), n),
+	)
+}
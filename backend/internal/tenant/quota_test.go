@@ -0,0 +1,93 @@
+package tenant
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// memQuota mirrors the store's conditional counter in memory.
+type memQuota map[string]int64
+
+func (m memQuota) ReserveQuota(_ context.Context, tenantID, period string, n, hard int) (int64, bool, error) {
+	key := tenantID + "|" + period
+	if hard > 0 && m[key]+int64(n) > int64(hard) {
+		return m[key], false, nil
+	}
+	m[key] += int64(n)
+	return m[key], true, nil
+}
+
+func (m memQuota) ReleaseQuota(_ context.Context, tenantID, period string, n int) error {
+	m[tenantID+"|"+period] -= int64(n)
+	return nil
+}
+
+func TestReserveQuota(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	day, month := "acme|2026-10-19", "acme|2026-10"
+
+	cfg := Config{
+		DailyQuota:   Quota{Soft: 3, Hard: 5},
+		MonthlyQuota: Quota{Hard: 8},
+	}
+
+	t.Run("counts and warns past soft", func(t *testing.T) {
+		st := memQuota{}
+		check, err := cfg.ReserveQuota(ctx, st, "acme", 4, now)
+		if err != nil || check.Exceeded {
+			t.Fatalf("ReserveQuota = %+v, %v", check, err)
+		}
+		if !strings.Contains(check.Warning, "daily soft quota") {
+			t.Errorf("Warning = %q", check.Warning)
+		}
+		if st[day] != 4 || st[month] != 4 {
+			t.Errorf("counters = %v", st)
+		}
+	})
+
+	t.Run("hard quota refuses without counting", func(t *testing.T) {
+		st := memQuota{day: 4, month: 4}
+		check, err := cfg.ReserveQuota(ctx, st, "acme", 2, now)
+		if err != nil || !check.Exceeded {
+			t.Fatalf("ReserveQuota = %+v, %v; want exceeded", check, err)
+		}
+		if !check.ResetAt.Equal(time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("ResetAt = %v, want next UTC midnight", check.ResetAt)
+		}
+		if st[day] != 4 || st[month] != 4 {
+			t.Errorf("counters = %v, want unchanged", st)
+		}
+	})
+
+	t.Run("monthly refusal gives back the daily reservation", func(t *testing.T) {
+		st := memQuota{month: 7}
+		check, err := cfg.ReserveQuota(ctx, st, "acme", 2, now)
+		if err != nil || !check.Exceeded || !strings.HasPrefix(check.Reason, "monthly") {
+			t.Fatalf("ReserveQuota = %+v, %v; want monthly exceeded", check, err)
+		}
+		if st[day] != 0 || st[month] != 7 {
+			t.Errorf("counters = %v, want daily refunded", st)
+		}
+	})
+
+	t.Run("more than the hard quota at once", func(t *testing.T) {
+		st := memQuota{}
+		check, err := cfg.ReserveQuota(ctx, st, "acme", 6, now)
+		if err != nil || !check.Exceeded || !strings.Contains(check.Reason, "more than the quota") {
+			t.Fatalf("ReserveQuota = %+v, %v", check, err)
+		}
+	})
+
+	t.Run("release gives back both periods", func(t *testing.T) {
+		st := memQuota{day: 4, month: 6}
+		if err := ReleaseQuota(ctx, st, "acme", 3, now); err != nil {
+			t.Fatal(err)
+		}
+		if st[day] != 1 || st[month] != 3 {
+			t.Errorf("counters = %v", st)
+		}
+	})
+}
@@ -0,0 +1,84 @@
+package tenant
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"safe-notify/internal/models"
+	"safe-notify/internal/ratelimit"
+)
+
+// Config overrides the deployment defaults for one tenant. Zero fields keep
+// the default.
+type Config struct {
+	FromEmail    string          `json:"fromEmail"`    // SES sender identity, must be verified
+	TemplatesDir string          `json:"templatesDir"` // EMAIL <event_type>.subject.tmpl / <event_type>.body.tmpl
+	MaxAttempts  int             `json:"maxAttempts"`  // per task, default 3
+	BackoffMs    []int64         `json:"backoffMs"`    // wait after attempt 1, 2, ...; the last one repeats
+	RateLimit    *ratelimit.Rule `json:"rateLimit"`    // outbound cap, the tenant:<id> bucket
+	DailyQuota   Quota           `json:"dailyQuota"`   // accepted tasks per UTC day
+	MonthlyQuota Quota           `json:"monthlyQuota"` // accepted tasks per UTC month
+}
+
+// Quota caps the tasks POST /events and recurring schedules may create in a
+// period. Past Soft, requests still succeed but carry a warning; past Hard
+// they get a 429 and schedule occurrences are skipped. Zero means no limit.
+type Quota struct {
+	Soft int `json:"soft"`
+	Hard int `json:"hard"`
+}
+
+// Registry is every configured tenant by ID. Tenants without an entry use
+// the defaults.
+type Registry map[string]Config
+
+// Load reads TENANTS, a JSON object of tenant ID -> Config.
+func Load() (Registry, error) {
+	reg := Registry{}
+	raw := os.Getenv("TENANTS")
+	if raw == "" {
+		return reg, nil
+	}
+	if err := json.Unmarshal([]byte(raw), &reg); err != nil {
+		return nil, fmt.Errorf("TENANTS must be a JSON object of tenant ID -> config: %w", err)
+	}
+	for id, c := range reg {
+		if !models.ValidTenantID(id) {
+			return nil, fmt.Errorf("TENANTS: invalid tenant ID %q", id)
+		}
+		if c.MaxAttempts < 0 {
+			return nil, fmt.Errorf("TENANTS: %s: maxAttempts must be >= 0", id)
+		}
+		for name, q := range map[string]Quota{"dailyQuota": c.DailyQuota, "monthlyQuota": c.MonthlyQuota} {
+			if q.Soft < 0 || q.Hard < 0 {
+				return nil, fmt.Errorf("TENANTS: %s: %s limits must be >= 0", id, name)
+			}
+		}
+	}
+	return reg, nil
+}
+
+func (r Registry) Get(id string) Config {
+	return r[models.TenantOrDefault(id)]
+}
+
+// MaxAttempts is the attempt budget for new tasks of tenant id.
+func (r Registry) MaxAttempts(id string) int {
+	if n := r.Get(id).MaxAttempts; n > 0 {
+		return n
+	}
+	return 3
+}
+
+// Backoff returns the tenant's wait after the given attempt, or false to use
+// the worker's default schedule.
+func (c Config) Backoff(attempt int) (int64, bool) {
+	if len(c.BackoffMs) == 0 || attempt < 1 {
+		return 0, false
+	}
+	if attempt > len(c.BackoffMs) {
+		attempt = len(c.BackoffMs)
+	}
+	return c.BackoffMs[attempt-1], true
+}
@@ -0,0 +1,73 @@
+package unsubscribe
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strings"
+)
+
+var ErrInvalidToken = errors.New("invalid unsubscribe token")
+
+// Signer builds and verifies HMAC-signed unsubscribe tokens.
+// A token is base64url("<recipient>\n<eventType>\n<tenant>") + "." + base64url(hmac).
+// Tokens from before tenants existed have no tenant line and mean the
+// default tenant.
+type Signer struct {
+	secret  []byte
+	baseURL string // public API base, e.g. https://notify.example.com
+}
+
+// DefaultBaseURL is used when PUBLIC_API_URL is unset. The API and worker
+// must agree on it, so both take it from here.
+const DefaultBaseURL = "http://localhost:8080"
+
+func NewSigner(secret, baseURL string) *Signer {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &Signer{secret: []byte(secret), baseURL: strings.TrimRight(baseURL, "/")}
+}
+
+func (s *Signer) Token(tenantID, recipient, eventType string) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(recipient + "\n" + eventType + "\n" + tenantID))
+	return payload + "." + base64.RawURLEncoding.EncodeToString(s.mac(payload))
+}
+
+// URL is the one-click endpoint used by both the List-Unsubscribe header and the footer.
+func (s *Signer) URL(tenantID, recipient, eventType string) string {
+	return s.baseURL + "/unsubscribe?token=" + url.QueryEscape(s.Token(tenantID, recipient, eventType))
+}
+
+// Verify returns what the token was issued for. tenantID is empty for
+// tokens issued before tenants existed.
+func (s *Signer) Verify(token string) (tenantID, recipient, eventType string, err error) {
+	payload, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return "", "", "", ErrInvalidToken
+	}
+	got, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(got, s.mac(payload)) {
+		return "", "", "", ErrInvalidToken
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return "", "", "", ErrInvalidToken
+	}
+	parts := strings.SplitN(string(raw), "\n", 3)
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", "", ErrInvalidToken
+	}
+	if len(parts) == 3 {
+		tenantID = parts[2]
+	}
+	return tenantID, parts[0], parts[1], nil
+}
+
+func (s *Signer) mac(payload string) []byte {
+	h := hmac.New(sha256.New, s.secret)
+	h.Write([]byte(payload))
+	return h.Sum(nil)
+}
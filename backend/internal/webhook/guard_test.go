@@ -0,0 +1,80 @@
+package webhook
+
+import (
+	"net"
+	"testing"
+)
+
+func TestIsPublicIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"100.64.0.1", false},
+		{"100.127.255.254", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"0.0.0.0", false},
+		{"0.1.2.3", false},
+		{"224.0.0.1", false},
+		{"255.255.255.255", false},
+	}
+	for _, tt := range tests {
+		if got := IsPublicIP(net.ParseIP(tt.ip)); got != tt.want {
+			t.Errorf("IsPublicIP(%s) = %v, want %v", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestCheckURL(t *testing.T) {
+	tests := []struct {
+		url string
+		ok  bool
+	}{
+		{"https://hooks.example.com/notify", true},
+		{"http://93.184.216.34:8080/cb", true},
+		{"ftp://hooks.example.com/", false},
+		{"/relative/path", false},
+		{"http://localhost:8080/cb", false},
+		{"http://LOCALHOST/cb", false},
+		{"http://api.localhost/cb", false},
+		{"http://127.0.0.1/cb", false},
+		{"http://10.0.0.5/cb", false},
+		{"http://192.168.0.10/cb", false},
+		{"http://169.254.169.254/latest/meta-data", false},
+		{"http://[::1]/cb", false},
+		{"http://[fd12::1]/cb", false},
+		{"http://0.0.0.0/cb", false},
+	}
+	for _, tt := range tests {
+		err := CheckURL(tt.url, false)
+		if (err == nil) != tt.ok {
+			t.Errorf("CheckURL(%q) = %v, want ok=%v", tt.url, err, tt.ok)
+		}
+	}
+
+	if err := CheckURL("http://localhost:8080/cb", true); err != nil {
+		t.Errorf("CheckURL with allowPrivate = %v", err)
+	}
+	if err := CheckURL("localhost:8080", true); err == nil {
+		t.Error("allowPrivate accepted a URL without a scheme")
+	}
+}
+
+func TestClientRefusesPrivateAddresses(t *testing.T) {
+	if err := publicOnly("tcp4", "127.0.0.1:80", nil); err == nil {
+		t.Error("dial to loopback allowed")
+	}
+	if err := publicOnly("tcp4", "93.184.216.34:443", nil); err != nil {
+		t.Errorf("dial to a public address refused: %v", err)
+	}
+}
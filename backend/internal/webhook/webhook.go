@@ -0,0 +1,78 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	SignatureHeader = "X-SafeNotify-Signature"
+	TimestampHeader = "X-SafeNotify-Timestamp"
+)
+
+// Sign returns "sha256=<hex>" over "<timestamp>.<body>".
+// Receivers recompute it and should reject stale timestamps.
+func Sign(secret string, ts int64, body []byte) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(h, "%d.", ts)
+	h.Write(body)
+	return "sha256=" + hex.EncodeToString(h.Sum(nil))
+}
+
+type Request struct {
+	URL     string
+	Body    []byte
+	Secret  string            // empty = unsigned
+	Headers map[string]string // extra headers
+}
+
+// Post sends a JSON POST and returns the response status code and headers.
+// A non-nil error means the request never got a response.
+func Post(ctx context.Context, client *http.Client, r Request) (int, http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.URL, bytes.NewReader(r.Body))
+	if err != nil {
+		return 0, nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "safe-notify")
+	for k, v := range r.Headers {
+		req.Header.Set(k, v)
+	}
+	if r.Secret != "" {
+		ts := time.Now().Unix()
+		req.Header.Set(TimestampHeader, strconv.FormatInt(ts, 10))
+		req.Header.Set(SignatureHeader, Sign(r.Secret, ts, r.Body))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	return resp.StatusCode, resp.Header, nil
+}
+
+// ParseRetryAfter reads a Retry-After header given in seconds or as an HTTP date.
+func ParseRetryAfter(h http.Header) (time.Duration, bool) {
+	v := h.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0), true
+	}
+	return 0, false
+}
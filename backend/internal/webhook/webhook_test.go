@@ -0,0 +1,82 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestPostSignsBody(t *testing.T) {
+	const secret = "whsec_test"
+	body := []byte(`{"event":"task.status_changed","task_id":"task_1"}`)
+
+	var got http.Header
+	var gotBody []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	code, _, err := Post(context.Background(), NewClient(5*time.Second, true), Request{
+		URL:     srv.URL,
+		Body:    body,
+		Secret:  secret,
+		Headers: map[string]string{"Idempotency-Key": "task_1"},
+	})
+	if err != nil || code != http.StatusAccepted {
+		t.Fatalf("Post = %d, %v", code, err)
+	}
+
+	// What a receiver does: recompute over the timestamp and raw body
+	ts, err := strconv.ParseInt(got.Get(TimestampHeader), 10, 64)
+	if err != nil {
+		t.Fatalf("%s = %q", TimestampHeader, got.Get(TimestampHeader))
+	}
+	if d := time.Since(time.Unix(ts, 0)); d < 0 || d > time.Minute {
+		t.Errorf("timestamp %d is not current", ts)
+	}
+	if sig := got.Get(SignatureHeader); sig != Sign(secret, ts, gotBody) {
+		t.Errorf("%s = %q, does not verify", SignatureHeader, sig)
+	}
+	if Sign("other", ts, gotBody) == got.Get(SignatureHeader) {
+		t.Error("signature verifies with the wrong secret")
+	}
+	if string(gotBody) != string(body) {
+		t.Errorf("body = %s", gotBody)
+	}
+	if got.Get("Content-Type") != "application/json" || got.Get("Idempotency-Key") != "task_1" {
+		t.Errorf("headers = %v", got)
+	}
+}
+
+func TestPostUnsignedWithoutSecret(t *testing.T) {
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	defer srv.Close()
+
+	if _, _, err := Post(context.Background(), NewClient(5*time.Second, true), Request{URL: srv.URL, Body: []byte(`{}`)}); err != nil {
+		t.Fatal(err)
+	}
+	if got.Get(SignatureHeader) != "" || got.Get(TimestampHeader) != "" {
+		t.Errorf("unsigned request carries %v", got)
+	}
+}
+
+func TestDefaultClientRefusesLoopback(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		t.Error("request reached a loopback server")
+	}))
+	defer srv.Close()
+
+	if _, _, err := Post(context.Background(), NewClient(5*time.Second, false), Request{URL: srv.URL, Body: []byte(`{}`)}); err == nil {
+		t.Fatal("Post to loopback succeeded")
+	}
+}
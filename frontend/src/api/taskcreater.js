@@ -15,6 +15,12 @@
 
 
 
+// Every request carries the API key from frontend/.env.local (VITE_API_KEY).
+// Local development only: Vite inlines VITE_* values into the bundle, so the
+// key is readable by anyone who loads the page. Deployed dashboards need a
+// proxy that adds the header server-side.
+const authHeaders = { Authorization: `Bearer ${import.meta.env.VITE_API_KEY ?? ""}` };
+
 export const api = {
   // UI can call this to prefill the Entity ID
   
@@ -25,7 +31,7 @@ export const api = {
      
   const res = await fetch("http://localhost:8080/events", {
     method: "POST",
-    headers: { "Content-Type": "application/json" },
+    headers: { ...authHeaders, "Content-Type": "application/json" },
     body: JSON.stringify(payload),
   });
   const data=await res.json();
@@ -37,7 +43,7 @@ export const api = {
 
   // Called by table polling
 async listNotifications() {
-  const res = await fetch("http://localhost:8080/notifications");
+  const res = await fetch("http://localhost:8080/notifications", { headers: authHeaders });
   
   return res.json();
 },
@@ -53,6 +59,7 @@ async replay(task_id) {
     `http://localhost:8080/tasks/${encodeURIComponent(task_id.task_id)}/replay`,
     {
       method: "POST",
+      headers: authHeaders,
     }
   );
 